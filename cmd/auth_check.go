@@ -20,8 +20,15 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/roverdotcom/checkbridge/github"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -33,24 +40,78 @@ var authCheckCommand = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		vip := viper.GetViper()
 		configureLogging(vip)
-		if err := runAuthCheck(vip); err != nil {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if repo, err := runAuthCheck(vip, os.Stdout, asJSON); err != nil {
 			logrus.WithError(err).Error("Auth check failed")
+			if hint := authHint(err, repo); hint != "" {
+				logrus.Info(hint)
+			}
 			os.Exit(2)
 		}
 	},
 }
 
-func runAuthCheck(c config) error {
+func init() {
+	authCheckCommand.Flags().Bool("json", false, "print the result of each step as JSON")
+}
+
+// runAuthCheck prints each step of authenticating for the configured
+// repository, returning the repository it resolved to
+func runAuthCheck(c config, out io.Writer, asJSON bool) (repo, error) {
 	repo, err := newRepo(c)
 	if err != nil {
-		return err
+		return repo, err
 	}
 
-	e := newEnvironment(c)
-	if _, err = e.githubToken(repo); err != nil {
-		return err
+	diagnosis := github.DiagnoseAuth(c, repo, defaultPerms)
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diagnosis); err != nil {
+			return repo, err
+		}
+	} else {
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		for _, step := range diagnosis.Steps {
+			detail := step.Detail
+			if step.Error != "" {
+				detail = step.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", step.Name, step.Status, detail)
+		}
+		if err := w.Flush(); err != nil {
+			return repo, err
+		}
 	}
 
+	if err := diagnosis.Err(); err != nil {
+		return repo, err
+	}
 	logrus.Info("Authentication succeeded")
-	return nil
+	return repo, nil
+}
+
+// authHint suggests how to fix a failed auth check, based on the GitHub API
+// error it failed with
+func authHint(err error, r repo) string {
+	apiErr := &github.APIError{}
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+
+	switch {
+	case apiErr.StatusCode == 401:
+		return "GitHub rejected the app's credentials: check --application-id matches the app --private-key was " +
+			"generated for, and that this machine's clock is correct"
+	case apiErr.StatusCode == 404 && strings.HasSuffix(apiErr.URL, "/installation"):
+		return fmt.Sprintf("The GitHub app isn't installed on %s/%s: install it from the app's settings page, "+
+			"or check --github-repo", r.Owner(), r.Name())
+	case apiErr.StatusCode == 404 && strings.HasSuffix(apiErr.URL, "/access_tokens"):
+		return "No installation of the GitHub app was found: check --installation-id, or leave it unset to look it up"
+	case apiErr.StatusCode == 422 && strings.HasSuffix(apiErr.URL, "/access_tokens"):
+		return "The GitHub app can't grant the requested permissions: give it read & write access to checks"
+	case apiErr.StatusCode == 403:
+		return "GitHub refused the request: check the app's permissions, and whether it is rate limited"
+	}
+	return ""
 }
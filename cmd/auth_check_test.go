@@ -20,14 +20,20 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/roverdotcom/checkbridge/github"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRunAuthCheck_NoData(t *testing.T) {
-	err := runAuthCheck(viper.New())
+	_, err := runAuthCheck(viper.New(), &bytes.Buffer{}, false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "missin")
 }
@@ -36,6 +42,52 @@ func TestRunAuthCheck_WithPresetToken(t *testing.T) {
 	vip := viper.New()
 	vip.Set("github-token", "fake-token")
 	vip.Set("github-repo", "foo/bar")
-	err := runAuthCheck(vip)
+	out := bytes.Buffer{}
+	r, err := runAuthCheck(vip, &out, false)
 	assert.NoError(t, err)
+	assert.Equal(t, repo{owner: "foo", name: "bar"}, r)
+	assert.Contains(t, out.String(), "explicit GitHub token")
+}
+
+func TestAuthHint(t *testing.T) {
+	// The repository may come from CI or the git remote rather than --github-repo
+	r := repo{owner: "foo", name: "bar"}
+
+	cases := []struct {
+		err      error
+		contains string
+	}{
+		{errors.New("no private key provided"), ""},
+		{&github.APIError{StatusCode: 401}, "clock"},
+		{&github.APIError{StatusCode: 404, URL: "https://api.github.com/repos/foo/bar/installation"}, "isn't installed on foo/bar"},
+		{&github.APIError{StatusCode: 404, URL: "https://api.github.com/app/installations/1/access_tokens"}, "--installation-id"},
+		{&github.APIError{StatusCode: 422, URL: "https://api.github.com/app/installations/1/access_tokens"}, "permissions"},
+		{&github.APIError{StatusCode: 500}, ""},
+	}
+	for _, tc := range cases {
+		wrapped := fmt.Errorf("auth failed: %w", tc.err)
+		hint := authHint(wrapped, r)
+		if tc.contains == "" {
+			assert.Empty(t, hint, tc.err.Error())
+		} else {
+			assert.Contains(t, hint, tc.contains, tc.err.Error())
+		}
+	}
+}
+
+func TestRunAuthCheck_JSON(t *testing.T) {
+	vip := viper.New()
+	vip.Set("github-repo", "foo/bar")
+	out := bytes.Buffer{}
+	_, err := runAuthCheck(vip, &out, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "private key")
+
+	diagnosis := github.AuthDiagnosis{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &diagnosis))
+	require.NotEmpty(t, diagnosis.Steps)
+	assert.Equal(t, "failed", diagnosis.Steps[0].Status)
+	for _, step := range diagnosis.Steps[1:] {
+		assert.Equal(t, "skipped", step.Status, step.Name)
+	}
 }
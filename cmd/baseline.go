@@ -0,0 +1,265 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const defaultBaselinePath = ".checkbridge-baseline.json"
+
+const baselineVersion = 1
+
+// baselineContextLines is the number of lines around an annotation included
+// in its fingerprint
+const baselineContextLines = 1
+
+var numberRegex = regexp.MustCompile(`[0-9]+`)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage the baseline of known issues",
+}
+
+var baselineWriteCmd = &cobra.Command{
+	Use:   "write",
+	Short: "Record the issues reported by a tool as the baseline",
+	Run: func(cmd *cobra.Command, args []string) {
+		v := viper.GetViper()
+		parserName, _ := cmd.Flags().GetString("parser")
+		input := mustGetInput(v)
+		defer input.Close()
+		if code := runBaselineWrite(v, parserName, input); code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	baselineWriteCmd.Flags().String("parser", "", fmt.Sprintf("parser for the tool output (one of %s)", strings.Join(builtinParserNames(), ", ")))
+	baselineWriteCmd.MarkFlagRequired("parser")
+	baselineCmd.AddCommand(baselineWriteCmd)
+}
+
+func runBaselineWrite(c config, parserName string, input io.Reader) int {
+	configureLogging(c)
+	pfunc, ok := builtinParsers[parserName]
+	if !ok {
+		logrus.Errorf("Unknown parser %q, expected one of: %s", parserName, strings.Join(builtinParserNames(), ", "))
+		return 2
+	}
+
+	result, err := pfunc(input).Run()
+	if err != nil {
+		logrus.WithError(err).Errorf("Error parsing %s results", parserName)
+		return 3
+	}
+
+	path := c.GetString("baseline")
+	if path == "" {
+		path = defaultBaselinePath
+	}
+	b := newBaseline(result, newFingerprinter())
+	if err := b.write(path); err != nil {
+		logrus.WithError(err).Error("Unable to write baseline")
+		return 3
+	}
+	logrus.Infof("Wrote %d known issues to %s", len(b.Issues), path)
+	return 0
+}
+
+// baselineIssue is a known issue recorded in a baseline file
+type baselineIssue struct {
+	Fingerprint string `json:"fingerprint"`
+	Path        string `json:"path"`
+	Message     string `json:"message"`
+}
+
+// baseline is the set of known issues, committed to the repository so only
+// new issues are annotated
+type baseline struct {
+	Version int             `json:"version"`
+	Issues  []baselineIssue `json:"issues"`
+}
+
+func newBaseline(result parser.Result, f fingerprinter) baseline {
+	issues := []baselineIssue{}
+	for _, a := range result.Annotations {
+		issues = append(issues, baselineIssue{
+			Fingerprint: f.fingerprint(a),
+			Path:        a.Path,
+			Message:     a.Message,
+		})
+	}
+	// Keep the file stable between runs so diffs only show real changes
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+		if issues[i].Message != issues[j].Message {
+			return issues[i].Message < issues[j].Message
+		}
+		return issues[i].Fingerprint < issues[j].Fingerprint
+	})
+
+	return baseline{
+		Version: baselineVersion,
+		Issues:  issues,
+	}
+}
+
+func readBaseline(path string) (baseline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return baseline{}, err
+	}
+	b := baseline{}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return baseline{}, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	if b.Version != baselineVersion {
+		return baseline{}, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+	return b, nil
+}
+
+func (b baseline) write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// filter drops annotations matching known issues, returning how many were
+// dropped. Each known issue only matches once, so new duplicates of a known
+// issue are still reported.
+func (b baseline) filter(result parser.Result, f fingerprinter) (parser.Result, int) {
+	known := map[string]int{}
+	for _, issue := range b.Issues {
+		known[issue.Fingerprint]++
+	}
+
+	annotations := []parser.Annotation{}
+	for _, a := range result.Annotations {
+		fingerprint := f.fingerprint(a)
+		if known[fingerprint] > 0 {
+			known[fingerprint]--
+			continue
+		}
+		annotations = append(annotations, a)
+	}
+	dropped := len(result.Annotations) - len(annotations)
+	result.Annotations = annotations
+	return result, dropped
+}
+
+// fingerprinter identifies annotations by their path, normalized message
+// and the source around them, rather than line numbers, so fingerprints
+// survive code moving around in the file
+type fingerprinter struct {
+	sources map[string][]string
+}
+
+func newFingerprinter() fingerprinter {
+	return fingerprinter{
+		sources: map[string][]string{},
+	}
+}
+
+func (f fingerprinter) sourceLines(path string) []string {
+	if lines, ok := f.sources[path]; ok {
+		return lines
+	}
+	var lines []string
+	if data, err := ioutil.ReadFile(path); err != nil {
+		logrus.WithError(err).WithField("path", path).Debug("Unable to read source for fingerprint")
+	} else {
+		lines = strings.Split(string(data), "\n")
+	}
+	f.sources[path] = lines
+	return lines
+}
+
+func (f fingerprinter) context(a parser.Annotation) string {
+	lines := f.sourceLines(a.Path)
+	if a.Line < 1 || a.Line > len(lines) {
+		return ""
+	}
+	start := a.Line - 1 - baselineContextLines
+	if start < 0 {
+		start = 0
+	}
+	end := a.Line + baselineContextLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	context := []string{}
+	for _, line := range lines[start:end] {
+		context = append(context, strings.TrimSpace(line))
+	}
+	return strings.Join(context, "\n")
+}
+
+func normalizeMessage(message string) string {
+	message = strings.Join(strings.Fields(message), " ")
+	return numberRegex.ReplaceAllString(message, "#")
+}
+
+func (f fingerprinter) fingerprint(a parser.Annotation) string {
+	hash := sha256.New()
+	for _, part := range []string{a.Path, a.Title, normalizeMessage(a.Message), f.context(a)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// filterBaseline drops annotations for known issues in the baseline file
+func (p parseRunner) filterBaseline(result parser.Result, path string) (parser.Result, error) {
+	b, err := readBaseline(path)
+	if err != nil {
+		return result, err
+	}
+
+	result, dropped := b.filter(result, newFingerprinter())
+	logrus.WithField("baseline", path).Infof("Dropped %d annotations for known issues", dropped)
+	if dropped > 0 {
+		result.Summary = appendSummary(result.Summary, fmt.Sprintf(
+			"Not annotated: %d known %s from the baseline.", dropped, pluralize("issue", dropped),
+		))
+	}
+	return result, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withTempDir(t *testing.T, action func(dir string)) {
+	dir, err := ioutil.TempDir("", "checkbridge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	action(dir)
+}
+
+func TestBaseline_SurvivesLineShifts(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		source := filepath.Join(dir, "main.py")
+		require.NoError(t, ioutil.WriteFile(source, []byte("import os\n\ndef main():\n    return foo\n"), 0644))
+
+		known := parser.Annotation{Path: source, Line: 4, Message: `Name "foo" is not defined`}
+		b := newBaseline(parser.Result{Annotations: []parser.Annotation{known}}, newFingerprinter())
+		baselinePath := filepath.Join(dir, "baseline.json")
+		require.NoError(t, b.write(baselinePath))
+
+		// Two new lines shift the known issue down, and add a new issue
+		require.NoError(t, ioutil.WriteFile(source, []byte("import os\nimport sys\n\n\ndef main():\n    return foo\n\nbar()\n"), 0644))
+		result := parser.Result{
+			Annotations: []parser.Annotation{
+				{Path: source, Line: 6, Message: `Name "foo" is not defined`},
+				{Path: source, Line: 8, Message: `Name "bar" is not defined`},
+			},
+		}
+
+		p := parseRunner{
+			environment: newEnvironment(viper.New()),
+		}
+		filtered, err := p.filterBaseline(result, baselinePath)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(filtered.Annotations))
+		assert.Equal(t, 8, filtered.Annotations[0].Line)
+		assert.Contains(t, filtered.Summary, "1 known issue from the baseline")
+	})
+}
+
+func TestBaseline_MatchesOncePerIssue(t *testing.T) {
+	f := newFingerprinter()
+	a := parser.Annotation{Path: "does/not/exist.go", Line: 1, Message: "exported function Foo should have comment"}
+	b := newBaseline(parser.Result{Annotations: []parser.Annotation{a}}, f)
+
+	filtered, dropped := b.filter(parser.Result{Annotations: []parser.Annotation{a, a}}, f)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, 1, len(filtered.Annotations))
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	assert.Equal(t, "Line too long (# > #)", normalizeMessage("Line too long  (120 > 100)\n"))
+}
+
+func TestReadBaseline_Invalid(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		path := filepath.Join(dir, "baseline.json")
+
+		_, err := readBaseline(path)
+		assert.Error(t, err)
+
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"version": 99}`), 0644))
+		_, err = readBaseline(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestRunBaselineWrite(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		path := filepath.Join(dir, "baseline.json")
+		vip := viper.New()
+		vip.Set("baseline", path)
+
+		input := bytes.NewBufferString("main.py:6: error: Name \"foo\" is not defined\n")
+		require.Equal(t, 0, runBaselineWrite(vip, "mypy", input))
+
+		b, err := readBaseline(path)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(b.Issues))
+		assert.Equal(t, "main.py", b.Issues[0].Path)
+	})
+}
+
+func TestRunBaselineWrite_UnknownParser(t *testing.T) {
+	assert.Equal(t, 2, runBaselineWrite(viper.New(), "not-a-parser", nil))
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// getenv is a variable so tests can stub the environment
+var getenv = os.Getenv
+
+// shaRegex matches full commit SHAs, as opposed to refs like "HEAD"
+var shaRegex = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// ciInfo is what a CI system's environment tells us about a build. Any of
+// it may be missing.
+type ciInfo struct {
+	provider string
+	// repo is either "owner/name" or the URL of the git remote
+	repo        string
+	sha         string
+	detailsURL  string
+	pullRequest string
+	// baseRef is the branch a pull request is merged into
+	baseRef string
+}
+
+// ciProvider reads build information from a CI system's environment, when
+// its detect environment variable is set
+type ciProvider struct {
+	name   string
+	detect string
+	info   func() ciInfo
+}
+
+var ciProviders = []ciProvider{
+	{"GitHub Actions", "GITHUB_ACTIONS", githubActionsInfo},
+	{"Buildkite", "BUILDKITE", buildkiteInfo},
+	{"CircleCI", "CIRCLECI", circleCIInfo},
+	{"GitLab CI", "GITLAB_CI", gitlabInfo},
+	{"Jenkins", "JENKINS_URL", jenkinsInfo},
+	{"Travis CI", "TRAVIS", travisInfo},
+	{"Azure Pipelines", "TF_BUILD", azureInfo},
+	{"TeamCity", "TEAMCITY_VERSION", teamCityInfo},
+	{"Drone", "DRONE", droneInfo},
+}
+
+// detectCI returns information about the build from the CI system it's
+// running on, if any
+func detectCI() ciInfo {
+	for _, provider := range ciProviders {
+		if getenv(provider.detect) == "" {
+			continue
+		}
+		info := provider.info()
+		info.provider = provider.name
+		logrus.WithFields(logrus.Fields{
+			"provider":    info.provider,
+			"repo":        info.repo,
+			"sha":         info.sha,
+			"detailsURL":  info.detailsURL,
+			"pullRequest": info.pullRequest,
+			"baseRef":     info.baseRef,
+		}).Debug("Detected CI environment")
+		return info
+	}
+	return explicitEnvInfo()
+}
+
+// explicitEnvInfo reads the GitHub Actions and Buildkite variables earlier
+// versions of checkbridge used, so builds exporting them by hand on other
+// CI systems keep working
+func explicitEnvInfo() ciInfo {
+	info := ciInfo{
+		repo:       firstEnv("GITHUB_REPOSITORY", "BUILDKITE_REPO"),
+		sha:        firstEnv("GITHUB_SHA", "BUILDKITE_COMMIT"),
+		detailsURL: getenv("BUILDKITE_BUILD_URL"),
+		baseRef:    getenv("GITHUB_BASE_REF"),
+	}
+	if info != (ciInfo{}) {
+		info.provider = "environment"
+		logrus.WithFields(logrus.Fields{
+			"repo":       info.repo,
+			"sha":        info.sha,
+			"detailsURL": info.detailsURL,
+			"baseRef":    info.baseRef,
+		}).Debug("Using build information from environment variables")
+	}
+	return info
+}
+
+// firstEnv returns the value of the first of names which is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// pullRequestEnv returns the value of name unless it's empty or "false",
+// which some CI systems set for builds that aren't for a pull request
+func pullRequestEnv(name string) string {
+	if value := getenv(name); value != "false" {
+		return value
+	}
+	return ""
+}
+
+// githubEvent is the part of a GitHub actions event payload we use
+type githubEvent struct {
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+}
+
+func readGithubEvent(path string) (githubEvent, error) {
+	event := githubEvent{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return event, fmt.Errorf("error reading GitHub event: %w", err)
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return event, fmt.Errorf("error parsing GitHub event %s: %w", path, err)
+	}
+	return event, nil
+}
+
+func githubActionsInfo() ciInfo {
+	info := ciInfo{
+		repo:    getenv("GITHUB_REPOSITORY"),
+		sha:     getenv("GITHUB_SHA"),
+		baseRef: getenv("GITHUB_BASE_REF"),
+	}
+	if server, runID := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_RUN_ID"); server != "" && runID != "" {
+		info.detailsURL = fmt.Sprintf("%s/%s/actions/runs/%s", server, info.repo, runID)
+	}
+
+	// In pull_request workflows GITHUB_SHA is a merge commit which isn't
+	// shown on the pull request, so use the pull request's head instead
+	if path := getenv("GITHUB_EVENT_PATH"); path != "" {
+		event, err := readGithubEvent(path)
+		if err != nil {
+			logrus.WithError(err).Debug("Unable to read GitHub event")
+		} else if event.PullRequest != nil {
+			logrus.Debug("Using pull request head SHA from GITHUB_EVENT_PATH")
+			info.sha = event.PullRequest.Head.SHA
+			info.pullRequest = fmt.Sprint(event.PullRequest.Number)
+		}
+	}
+	return info
+}
+
+func buildkiteInfo() ciInfo {
+	info := ciInfo{
+		repo:        getenv("BUILDKITE_REPO"),
+		detailsURL:  getenv("BUILDKITE_BUILD_URL"),
+		pullRequest: pullRequestEnv("BUILDKITE_PULL_REQUEST"),
+		baseRef:     getenv("BUILDKITE_PULL_REQUEST_BASE_BRANCH"),
+	}
+	// Buildkite sets "HEAD" when a build isn't for a specific commit
+	if sha := getenv("BUILDKITE_COMMIT"); shaRegex.MatchString(sha) {
+		info.sha = sha
+	}
+
+	// When building a pull request's merge refspec, the checkout is a merge
+	// commit whose second parent is the pull request's head
+	if getenv("BUILDKITE_PULL_REQUEST_USING_MERGE_REFSPEC") == "true" {
+		sha, err := runGit("rev-parse", "HEAD^2")
+		if err != nil {
+			logrus.WithError(err).Debug("Unable to find pull request head of Buildkite merge refspec")
+		} else {
+			logrus.Debug("Using pull request head SHA of Buildkite merge refspec")
+			info.sha = sha
+		}
+	}
+	return info
+}
+
+func circleCIInfo() ciInfo {
+	info := ciInfo{
+		sha:        getenv("CIRCLE_SHA1"),
+		detailsURL: getenv("CIRCLE_BUILD_URL"),
+		repo:       getenv("CIRCLE_REPOSITORY_URL"),
+	}
+	if user, name := getenv("CIRCLE_PROJECT_USERNAME"), getenv("CIRCLE_PROJECT_REPONAME"); user != "" && name != "" {
+		info.repo = user + "/" + name
+	}
+	// CIRCLE_PULL_REQUEST is the pull request's URL, ending in its number
+	if url := getenv("CIRCLE_PULL_REQUEST"); url != "" {
+		info.pullRequest = url[strings.LastIndex(url, "/")+1:]
+	}
+	return info
+}
+
+func gitlabInfo() ciInfo {
+	return ciInfo{
+		// Builds of GitHub pull requests in mirrored projects are "external
+		// pull requests"
+		repo:        getenv("CI_PROJECT_PATH"),
+		sha:         firstEnv("CI_EXTERNAL_PULL_REQUEST_SOURCE_BRANCH_SHA", "CI_COMMIT_SHA"),
+		detailsURL:  getenv("CI_JOB_URL"),
+		pullRequest: firstEnv("CI_EXTERNAL_PULL_REQUEST_IID", "CI_MERGE_REQUEST_IID"),
+		baseRef:     firstEnv("CI_EXTERNAL_PULL_REQUEST_TARGET_BRANCH_NAME", "CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+	}
+}
+
+func jenkinsInfo() ciInfo {
+	// Multibranch pipelines set CHANGE_*, the GitHub pull request builder
+	// plugin sets ghprb*
+	return ciInfo{
+		repo:        getenv("GIT_URL"),
+		sha:         firstEnv("ghprbActualCommit", "GIT_COMMIT"),
+		detailsURL:  getenv("BUILD_URL"),
+		pullRequest: firstEnv("CHANGE_ID", "ghprbPullId"),
+		baseRef:     firstEnv("CHANGE_TARGET", "ghprbTargetBranch"),
+	}
+}
+
+func travisInfo() ciInfo {
+	info := ciInfo{
+		repo:        getenv("TRAVIS_REPO_SLUG"),
+		sha:         firstEnv("TRAVIS_PULL_REQUEST_SHA", "TRAVIS_COMMIT"),
+		detailsURL:  getenv("TRAVIS_BUILD_WEB_URL"),
+		pullRequest: pullRequestEnv("TRAVIS_PULL_REQUEST"),
+	}
+	// For pull requests, TRAVIS_BRANCH is the branch being merged into
+	if info.pullRequest != "" {
+		info.baseRef = getenv("TRAVIS_BRANCH")
+	}
+	return info
+}
+
+func azureInfo() ciInfo {
+	info := ciInfo{
+		repo:        getenv("BUILD_REPOSITORY_NAME"),
+		sha:         firstEnv("SYSTEM_PULLREQUEST_SOURCECOMMITID", "BUILD_SOURCEVERSION"),
+		pullRequest: firstEnv("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER", "SYSTEM_PULLREQUEST_PULLREQUESTID"),
+		baseRef:     strings.TrimPrefix(getenv("SYSTEM_PULLREQUEST_TARGETBRANCH"), "refs/heads/"),
+	}
+	if collection, project, buildID := getenv("SYSTEM_COLLECTIONURI"), getenv("SYSTEM_TEAMPROJECT"), getenv("BUILD_BUILDID"); collection != "" && buildID != "" {
+		info.detailsURL = fmt.Sprintf("%s%s/_build/results?buildId=%s", collection, project, buildID)
+	}
+	return info
+}
+
+func teamCityInfo() ciInfo {
+	// TeamCity only passes the commit by default, the rest has to be passed
+	// as parameters
+	return ciInfo{
+		sha: getenv("BUILD_VCS_NUMBER"),
+	}
+}
+
+func droneInfo() ciInfo {
+	info := ciInfo{
+		repo:        getenv("DRONE_REPO"),
+		sha:         getenv("DRONE_COMMIT_SHA"),
+		detailsURL:  getenv("DRONE_BUILD_LINK"),
+		pullRequest: getenv("DRONE_PULL_REQUEST"),
+	}
+	if info.pullRequest != "" {
+		info.baseRef = getenv("DRONE_TARGET_BRANCH")
+	}
+	return info
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the CI running the tests from being detected
+	getenv = func(string) string { return "" }
+	os.Exit(m.Run())
+}
+
+// withEnv stubs the environment with env, returning a function restoring
+// the empty test environment
+func withEnv(env map[string]string) func() {
+	getenv = func(name string) string {
+		return env[name]
+	}
+	return func() {
+		getenv = func(string) string { return "" }
+	}
+}
+
+const (
+	headSha  = "1111111111111111111111111111111111111111"
+	mergeSha = "2222222222222222222222222222222222222222"
+)
+
+func TestDetectCI(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		pullRequestEvent := filepath.Join(dir, "pull_request.json")
+		require.NoError(t, ioutil.WriteFile(pullRequestEvent, []byte(`{"pull_request": {"number": 7, "head": {"sha": "`+headSha+`"}}}`), 0644))
+		pushEvent := filepath.Join(dir, "push.json")
+		require.NoError(t, ioutil.WriteFile(pushEvent, []byte(`{"after": "`+mergeSha+`"}`), 0644))
+
+		cases := []struct {
+			name     string
+			env      map[string]string
+			expected ciInfo
+		}{
+			{"none", map[string]string{"CI": "true"}, ciInfo{}},
+			{
+				"explicit variables",
+				map[string]string{
+					"GITHUB_REPOSITORY": "org/repo",
+					"BUILDKITE_REPO":    "git@github.com:other/repo.git",
+					"BUILDKITE_COMMIT":  headSha,
+				},
+				ciInfo{provider: "environment", repo: "org/repo", sha: headSha},
+			},
+			{
+				"GitHub Actions pull request",
+				map[string]string{
+					"GITHUB_ACTIONS":    "true",
+					"GITHUB_REPOSITORY": "org/repo",
+					"GITHUB_SHA":        mergeSha,
+					"GITHUB_EVENT_PATH": pullRequestEvent,
+					"GITHUB_BASE_REF":   "main",
+					"GITHUB_SERVER_URL": "https://github.com",
+					"GITHUB_RUN_ID":     "99",
+				},
+				ciInfo{"GitHub Actions", "org/repo", headSha, "https://github.com/org/repo/actions/runs/99", "7", "main"},
+			},
+			{
+				"GitHub Actions push",
+				map[string]string{
+					"GITHUB_ACTIONS":    "true",
+					"GITHUB_REPOSITORY": "org/repo",
+					"GITHUB_SHA":        mergeSha,
+					"GITHUB_EVENT_PATH": pushEvent,
+				},
+				ciInfo{provider: "GitHub Actions", repo: "org/repo", sha: mergeSha},
+			},
+			{
+				"GitHub Actions unreadable event",
+				map[string]string{
+					"GITHUB_ACTIONS":    "true",
+					"GITHUB_SHA":        mergeSha,
+					"GITHUB_EVENT_PATH": filepath.Join(dir, "missing.json"),
+				},
+				ciInfo{provider: "GitHub Actions", sha: mergeSha},
+			},
+			{
+				"Buildkite",
+				map[string]string{
+					"BUILDKITE":                          "true",
+					"BUILDKITE_REPO":                     "git@github.com:org/repo.git",
+					"BUILDKITE_COMMIT":                   headSha,
+					"BUILDKITE_BUILD_URL":                "https://buildkite.com/org/pipeline/builds/1",
+					"BUILDKITE_PULL_REQUEST":             "7",
+					"BUILDKITE_PULL_REQUEST_BASE_BRANCH": "main",
+				},
+				ciInfo{"Buildkite", "git@github.com:org/repo.git", headSha, "https://buildkite.com/org/pipeline/builds/1", "7", "main"},
+			},
+			{
+				"Buildkite branch build",
+				map[string]string{
+					"BUILDKITE":              "true",
+					"BUILDKITE_COMMIT":       "HEAD",
+					"BUILDKITE_PULL_REQUEST": "false",
+				},
+				ciInfo{provider: "Buildkite"},
+			},
+			{
+				"CircleCI",
+				map[string]string{
+					"CIRCLECI":                "true",
+					"CIRCLE_PROJECT_USERNAME": "org",
+					"CIRCLE_PROJECT_REPONAME": "repo",
+					"CIRCLE_SHA1":             headSha,
+					"CIRCLE_BUILD_URL":        "https://circleci.com/gh/org/repo/1",
+					"CIRCLE_PULL_REQUEST":     "https://github.com/org/repo/pull/7",
+				},
+				ciInfo{"CircleCI", "org/repo", headSha, "https://circleci.com/gh/org/repo/1", "7", ""},
+			},
+			{
+				"GitLab external pull request",
+				map[string]string{
+					"GITLAB_CI":       "true",
+					"CI_PROJECT_PATH": "org/repo",
+					"CI_COMMIT_SHA":   mergeSha,
+					"CI_EXTERNAL_PULL_REQUEST_SOURCE_BRANCH_SHA": headSha,
+					"CI_JOB_URL":                                  "https://gitlab.com/org/repo/-/jobs/1",
+					"CI_EXTERNAL_PULL_REQUEST_IID":                "7",
+					"CI_EXTERNAL_PULL_REQUEST_TARGET_BRANCH_NAME": "main",
+				},
+				ciInfo{"GitLab CI", "org/repo", headSha, "https://gitlab.com/org/repo/-/jobs/1", "7", "main"},
+			},
+			{
+				"Jenkins multibranch",
+				map[string]string{
+					"JENKINS_URL":   "https://jenkins.example.com/",
+					"GIT_URL":       "git@github.com:org/repo.git",
+					"GIT_COMMIT":    headSha,
+					"BUILD_URL":     "https://jenkins.example.com/job/repo/1/",
+					"CHANGE_ID":     "7",
+					"CHANGE_TARGET": "main",
+				},
+				ciInfo{"Jenkins", "git@github.com:org/repo.git", headSha, "https://jenkins.example.com/job/repo/1/", "7", "main"},
+			},
+			{
+				"Jenkins pull request builder",
+				map[string]string{
+					"JENKINS_URL":       "https://jenkins.example.com/",
+					"GIT_COMMIT":        mergeSha,
+					"ghprbActualCommit": headSha,
+					"ghprbPullId":       "7",
+					"ghprbTargetBranch": "main",
+				},
+				ciInfo{provider: "Jenkins", sha: headSha, pullRequest: "7", baseRef: "main"},
+			},
+			{
+				"Travis pull request",
+				map[string]string{
+					"TRAVIS":                  "true",
+					"TRAVIS_REPO_SLUG":        "org/repo",
+					"TRAVIS_COMMIT":           mergeSha,
+					"TRAVIS_PULL_REQUEST_SHA": headSha,
+					"TRAVIS_BUILD_WEB_URL":    "https://travis-ci.com/org/repo/builds/1",
+					"TRAVIS_PULL_REQUEST":     "7",
+					"TRAVIS_BRANCH":           "main",
+				},
+				ciInfo{"Travis CI", "org/repo", headSha, "https://travis-ci.com/org/repo/builds/1", "7", "main"},
+			},
+			{
+				"Travis push",
+				map[string]string{
+					"TRAVIS":              "true",
+					"TRAVIS_REPO_SLUG":    "org/repo",
+					"TRAVIS_COMMIT":       mergeSha,
+					"TRAVIS_PULL_REQUEST": "false",
+					"TRAVIS_BRANCH":       "feature",
+				},
+				ciInfo{provider: "Travis CI", repo: "org/repo", sha: mergeSha},
+			},
+			{
+				"Azure Pipelines",
+				map[string]string{
+					"TF_BUILD":                             "True",
+					"BUILD_REPOSITORY_NAME":                "org/repo",
+					"BUILD_SOURCEVERSION":                  mergeSha,
+					"SYSTEM_PULLREQUEST_SOURCECOMMITID":    headSha,
+					"SYSTEM_PULLREQUEST_PULLREQUESTNUMBER": "7",
+					"SYSTEM_PULLREQUEST_TARGETBRANCH":      "refs/heads/main",
+					"SYSTEM_COLLECTIONURI":                 "https://dev.azure.com/org/",
+					"SYSTEM_TEAMPROJECT":                   "project",
+					"BUILD_BUILDID":                        "1",
+				},
+				ciInfo{"Azure Pipelines", "org/repo", headSha, "https://dev.azure.com/org/project/_build/results?buildId=1", "7", "main"},
+			},
+			{
+				"TeamCity",
+				map[string]string{
+					"TEAMCITY_VERSION": "2020.1",
+					"BUILD_VCS_NUMBER": headSha,
+				},
+				ciInfo{provider: "TeamCity", sha: headSha},
+			},
+			{
+				"Drone pull request",
+				map[string]string{
+					"DRONE":               "true",
+					"DRONE_REPO":          "org/repo",
+					"DRONE_COMMIT_SHA":    headSha,
+					"DRONE_BUILD_LINK":    "https://drone.example.com/org/repo/1",
+					"DRONE_PULL_REQUEST":  "7",
+					"DRONE_TARGET_BRANCH": "main",
+				},
+				ciInfo{"Drone", "org/repo", headSha, "https://drone.example.com/org/repo/1", "7", "main"},
+			},
+		}
+		for _, tc := range cases {
+			restore := withEnv(tc.env)
+			assert.Equal(t, tc.expected, detectCI(), tc.name)
+			restore()
+		}
+	})
+}
+
+func TestDetectCI_BuildkiteMergeRefspec(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git unavailable")
+	}
+	withTempDir(t, func(dir string) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer os.Chdir(wd)
+
+		git(t, "init", "-q")
+		git(t, "commit", "-q", "--allow-empty", "-m", "initial")
+		base := git(t, "rev-parse", "--abbrev-ref", "HEAD")
+		git(t, "checkout", "-q", "-b", "feature")
+		git(t, "commit", "-q", "--allow-empty", "-m", "feature")
+		feature := git(t, "rev-parse", "HEAD")
+		git(t, "checkout", "-q", base)
+		git(t, "commit", "-q", "--allow-empty", "-m", "base")
+		git(t, "merge", "-q", "--no-ff", "-m", "merge", "feature")
+
+		defer withEnv(map[string]string{
+			"BUILDKITE":        "true",
+			"BUILDKITE_COMMIT": git(t, "rev-parse", "HEAD"),
+			"BUILDKITE_PULL_REQUEST_USING_MERGE_REFSPEC": "true",
+		})()
+		assert.Equal(t, feature, detectCI().sha)
+	})
+}
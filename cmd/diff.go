@@ -0,0 +1,213 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/sirupsen/logrus"
+)
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -[0-9,]+ \+([0-9]+)(?:,([0-9]+))? @@`)
+
+type lineRange struct {
+	start int
+	end   int
+}
+
+// changedLines maps file paths to the ranges of lines added or modified in
+// them
+type changedLines map[string][]lineRange
+
+func runGit(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// resolveRef finds the commit for a ref, falling back to the ref on origin
+// since CI checkouts often only have remote branches
+func resolveRef(ref string) (string, error) {
+	sha, err := runGit("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err == nil {
+		return sha, nil
+	}
+	if remoteSha, remoteErr := runGit("rev-parse", "--verify", "--quiet", "origin/"+ref+"^{commit}"); remoteErr == nil {
+		return remoteSha, nil
+	}
+	return "", fmt.Errorf("unknown base ref %s: %w", ref, err)
+}
+
+// mergeBase finds where head diverged from the base ref. The base ref is read
+// from configuration or the pull request being built, falling back to the
+// default branch of origin.
+func mergeBase(c config, head string) (string, error) {
+	base := c.GetString("base-ref")
+	if base == "" {
+		base = detectCI().baseRef
+	}
+	if base == "" {
+		defaultBranch, err := runGit("symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+		if err != nil {
+			return "", errors.New("unable to determine base ref, pass --base-ref")
+		}
+		base = defaultBranch
+	}
+	logrus.WithField("ref", base).Debug("Using base ref for changed lines")
+
+	baseSha, err := resolveRef(base)
+	if err != nil {
+		return "", err
+	}
+	return runGit("merge-base", baseSha, head)
+}
+
+// changedSince computes the lines changed between base and head using git
+func changedSince(base string, head string) (changedLines, error) {
+	// Prefixes are explicit so settings like diff.noprefix don't change the paths
+	diff, err := runGit("diff", "--unified=0", "--no-color", "--no-ext-diff", "-M",
+		"--src-prefix=a/", "--dst-prefix=b/", base, head)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(strings.NewReader(diff))
+}
+
+// parseDiff reads the added and modified line ranges from a unified diff
+func parseDiff(reader io.Reader) (changedLines, error) {
+	changed := changedLines{}
+	file := ""
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "+++ ") {
+			file = diffPath(strings.TrimPrefix(line, "+++ "))
+			continue
+		}
+		if file == "" {
+			continue
+		}
+		match := hunkHeaderRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		start, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse hunk start %s: %w", match[1], err)
+		}
+		count := 1
+		if match[2] != "" {
+			if count, err = strconv.Atoi(match[2]); err != nil {
+				return nil, fmt.Errorf("parse hunk length %s: %w", match[2], err)
+			}
+		}
+		// Hunks only removing lines don't change anything that can be annotated
+		if count == 0 {
+			continue
+		}
+		changed[file] = append(changed[file], lineRange{start: start, end: start + count - 1})
+	}
+	return changed, scanner.Err()
+}
+
+func diffPath(name string) string {
+	// git ends the header with a tab when the path contains a space
+	name = strings.TrimSuffix(name, "\t")
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		name = unquoted
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(name, "b/")
+}
+
+func (c changedLines) contains(a parser.Annotation) bool {
+	ranges, ok := c[path.Clean(strings.TrimPrefix(a.Path, "./"))]
+	if !ok {
+		return false
+	}
+	// Annotations without a line apply to the whole file
+	if a.Line == 0 {
+		return true
+	}
+	end := a.EndLine
+	if end < a.Line {
+		end = a.Line
+	}
+	for _, r := range ranges {
+		if a.Line <= r.end && end >= r.start {
+			return true
+		}
+	}
+	return false
+}
+
+// filter drops annotations outside the changed lines, returning how many
+// were dropped
+func (c changedLines) filter(result parser.Result) (parser.Result, int) {
+	annotations := []parser.Annotation{}
+	for _, a := range result.Annotations {
+		if c.contains(a) {
+			annotations = append(annotations, a)
+		}
+	}
+	dropped := len(result.Annotations) - len(annotations)
+	result.Annotations = annotations
+	return result, dropped
+}
+
+// filterChanged restricts the result to annotations on lines changed by head
+func (p parseRunner) filterChanged(result parser.Result, head string) (parser.Result, error) {
+	base, err := mergeBase(p.config(), head)
+	if err != nil {
+		return result, err
+	}
+	changed, err := changedSince(base, head)
+	if err != nil {
+		return result, err
+	}
+
+	result, dropped := changed.filter(result)
+	logrus.WithField("base", base).Infof("Dropped %d annotations outside of changed lines", dropped)
+	if dropped > 0 && p.config().GetBool("count-unchanged") {
+		result.Summary = appendSummary(result.Summary, fmt.Sprintf(
+			"Not annotated: %d more %s outside of the changed lines.", dropped, pluralize("issue", dropped),
+		))
+	}
+	return result, nil
+}
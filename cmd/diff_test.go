@@ -0,0 +1,197 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDiff = `diff --git a/cmd/root.go b/cmd/root.go
+index 3b18e51..a9d2c4f 100644
+--- a/cmd/root.go
++++ b/cmd/root.go
+@@ -10,0 +11,2 @@ import (
++	"fmt"
++	"io"
+@@ -40 +42 @@ func configureLogging(c config) {
+-	old
++	new
+@@ -60,3 +61,0 @@ func init() {
+-	removed
+-	removed
+-	removed
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,3 @@
++package cmd
++
++var x = 1
+diff --git a/x y.go b/x y.go
+--- a/x y.go	
++++ b/x y.go	
+@@ -3 +3,2 @@ package main
+-old
++new
++newer
+`
+
+func TestParseDiff(t *testing.T) {
+	changed, err := parseDiff(strings.NewReader(testDiff))
+	require.NoError(t, err)
+
+	assert.Equal(t, changedLines{
+		"cmd/root.go": {{start: 11, end: 12}, {start: 42, end: 42}},
+		"new.go":      {{start: 1, end: 3}},
+		"x y.go":      {{start: 3, end: 4}},
+	}, changed)
+}
+
+func TestChangedLinesFilter(t *testing.T) {
+	changed := changedLines{
+		"cmd/root.go": {{start: 11, end: 12}, {start: 42, end: 42}},
+	}
+	result := parser.Result{
+		Annotations: []parser.Annotation{
+			{Path: "cmd/root.go", Line: 12, EndLine: 12},
+			{Path: "./cmd/root.go", Line: 40, EndLine: 43},
+			{Path: "cmd/root.go", Line: 13, EndLine: 13},
+			{Path: "cmd/root.go"},
+			{Path: "main.go", Line: 12, EndLine: 12},
+		},
+	}
+
+	filtered, dropped := changed.filter(result)
+	assert.Equal(t, 2, dropped)
+	require.Equal(t, 3, len(filtered.Annotations))
+	assert.Equal(t, 12, filtered.Annotations[0].Line)
+	assert.Equal(t, 40, filtered.Annotations[1].Line)
+	assert.Equal(t, 0, filtered.Annotations[2].Line)
+}
+
+func git(t *testing.T, args ...string) string {
+	out, err := runGit(append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	require.NoError(t, err)
+	return out
+}
+
+func TestFilterChanged_GitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git unavailable")
+	}
+	dir, err := ioutil.TempDir("", "checkbridge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	write := func(content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644))
+	}
+
+	git(t, "init", "-q")
+	write("package main\n\nfunc main() {\n}\n")
+	git(t, "add", "main.go")
+	git(t, "commit", "-q", "-m", "initial")
+	git(t, "branch", "base")
+	write("package main\n\nfunc main() {\n\tprintln()\n}\n")
+	git(t, "commit", "-q", "-a", "-m", "change")
+	head := git(t, "rev-parse", "HEAD")
+
+	vip := viper.New()
+	vip.Set("base-ref", "base")
+	vip.Set("count-unchanged", true)
+	p := parseRunner{
+		environment: newEnvironment(vip),
+	}
+	result, err := p.filterChanged(parser.Result{
+		Annotations: []parser.Annotation{
+			{Path: "main.go", Line: 1, EndLine: 1},
+			{Path: "main.go", Line: 4, EndLine: 4},
+		},
+	}, head)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Annotations))
+	assert.Equal(t, 4, result.Annotations[0].Line)
+	assert.Contains(t, result.Summary, "1 more issue outside of the changed lines")
+}
+
+func TestChangedSince_NoPrefix(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git unavailable")
+	}
+	dir, err := ioutil.TempDir("", "checkbridge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	// A directory named b would lose its name if the b/ prefix was assumed
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "b"), 0755))
+	path := filepath.Join(dir, "b", "main.go")
+	git(t, "init", "-q")
+	git(t, "config", "diff.noprefix", "true")
+	require.NoError(t, ioutil.WriteFile(path, []byte("package main\n"), 0644))
+	git(t, "add", ".")
+	git(t, "commit", "-q", "-m", "initial")
+	base := git(t, "rev-parse", "HEAD")
+	require.NoError(t, ioutil.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644))
+	git(t, "commit", "-q", "-a", "-m", "change")
+	head := git(t, "rev-parse", "HEAD")
+
+	changed, err := changedSince(base, head)
+	require.NoError(t, err)
+	assert.Equal(t, changedLines{"b/main.go": {{start: 2, end: 3}}}, changed)
+}
+
+func TestFilterChanged_UnknownBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git unavailable")
+	}
+	vip := viper.New()
+	vip.Set("base-ref", "checkbridge-test-missing-ref")
+	p := parseRunner{
+		environment: newEnvironment(vip),
+	}
+	_, err := p.filterChanged(parser.Result{}, "HEAD")
+	assert.Error(t, err)
+}
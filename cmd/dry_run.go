@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/roverdotcom/checkbridge/github"
+	"github.com/sirupsen/logrus"
+)
+
+var dryRunFormats = map[string]bool{
+	"":      true,
+	"json":  true,
+	"table": true,
+}
+
+func checkDryRunFormat(format string) error {
+	if !dryRunFormats[format] {
+		return fmt.Errorf("unknown dry run format %q, expected json or table", format)
+	}
+	return nil
+}
+
+// dryRunClient prints check runs instead of sending them to GitHub
+type dryRunClient struct {
+	out    io.Writer
+	format string
+	lastID int64
+}
+
+func newDryRunClient(out io.Writer, format string) (*dryRunClient, error) {
+	if err := checkDryRunFormat(format); err != nil {
+		return nil, err
+	}
+	return &dryRunClient{
+		out:    out,
+		format: format,
+	}, nil
+}
+
+func (d *dryRunClient) CreateCheck(run github.CheckRun) (int64, error) {
+	d.lastID++
+	logrus.WithField("id", d.lastID).Info("Dry run: not creating check run")
+	return d.lastID, d.print(run)
+}
+
+func (d *dryRunClient) UpdateCheck(run github.CheckRun) error {
+	logrus.WithField("id", run.ID).Info("Dry run: not updating check run")
+	return d.print(run)
+}
+
+func (d *dryRunClient) print(run github.CheckRun) error {
+	if d.format == "table" {
+		return d.printTable(run)
+	}
+	encoder := json.NewEncoder(d.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(run)
+}
+
+func (d *dryRunClient) printTable(run github.CheckRun) error {
+	status := string(run.Status)
+	if run.Conclusion != "" {
+		status = fmt.Sprintf("%s (%s)", status, run.Conclusion)
+	}
+	fmt.Fprintf(d.out, "%s: %s\n", run.Name, status)
+	if run.Output.Title != "" {
+		fmt.Fprintf(d.out, "%s\n", run.Output.Title)
+	}
+	if len(run.Output.Annotations) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(d.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LEVEL\tLOCATION\tMESSAGE")
+	for _, a := range run.Output.Annotations {
+		location := fmt.Sprintf("%s:%d", a.Path, a.Line)
+		if a.Column > 0 {
+			location = fmt.Sprintf("%s:%d", location, a.Column)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", a.Level, location, a.Message)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/github"
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dryRunCheck = github.CheckRun{
+	Name:       "golint",
+	HeadSHA:    "abc123",
+	Status:     github.CheckStatusCompleted,
+	Conclusion: github.CheckConclusionFailure,
+	Output: parser.Result{
+		Title: "1 warning",
+		Annotations: []parser.Annotation{{
+			Path:    "main.go",
+			Line:    12,
+			EndLine: 12,
+			Column:  3,
+			Level:   parser.LevelWarning,
+			Message: "exported function Foo should have comment",
+		}},
+	},
+}
+
+func TestDryRunClient_JSON(t *testing.T) {
+	out := bytes.Buffer{}
+	client, err := newDryRunClient(&out, "json")
+	require.NoError(t, err)
+
+	id, err := client.CreateCheck(dryRunCheck)
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+
+	printed := github.CheckRun{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &printed))
+	assert.Equal(t, dryRunCheck, printed)
+}
+
+func TestDryRunClient_Table(t *testing.T) {
+	out := bytes.Buffer{}
+	client, err := newDryRunClient(&out, "table")
+	require.NoError(t, err)
+
+	require.NoError(t, client.UpdateCheck(dryRunCheck))
+	assert.Contains(t, out.String(), "golint: completed (failure)")
+	assert.Contains(t, out.String(), "main.go:12:3")
+	assert.Contains(t, out.String(), "exported function Foo should have comment")
+}
+
+func TestDryRunClient_UnknownFormat(t *testing.T) {
+	_, err := newDryRunClient(&bytes.Buffer{}, "xml")
+	assert.Error(t, err)
+}
+
+func TestParseRunnerRun_DryRunWithoutCredentials(t *testing.T) {
+	vip := viper.New()
+	vip.Set("dry-run", true)
+	vip.Set("commit-sha", "abc123")
+
+	p := parseRunner{
+		environment: newEnvironment(vip),
+		name:        "golint",
+		parse: stubParser{
+			r: dryRunCheck.Output,
+		},
+	}
+	assert.Equal(t, 1, p.run())
+}
+
+func TestParseRunnerRun_DryRunBadFormat(t *testing.T) {
+	vip := viper.New()
+	vip.Set("dry-run", true)
+	vip.Set("dry-run-format", "xml")
+
+	p := parseRunner{
+		environment: newEnvironment(vip),
+	}
+	assert.Equal(t, 2, p.run())
+}
@@ -21,6 +21,8 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/roverdotcom/checkbridge/github"
 	"github.com/sirupsen/logrus"
 )
@@ -59,11 +61,16 @@ func (ce concreteEnv) githubToken(repo repo) (string, error) {
 }
 
 func (ce concreteEnv) apiClient(repo repo) (github.CheckClient, error) {
+	if ce.c.GetBool("dry-run") {
+		logrus.Debug("Dry run, skipping GitHub authentication")
+		return newDryRunClient(os.Stdout, ce.c.GetString("dry-run-format"))
+	}
+
 	token, err := ce.githubToken(repo)
 	if err != nil {
 		return nil, err
 	}
 	logrus.WithField("token", token).Debug("Got GitHub checks token")
 
-	return github.NewCheckClient(token, repo), nil
+	return github.NewCheckClient(token, repo, ce.c), nil
 }
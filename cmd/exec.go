@@ -0,0 +1,199 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// stderrTailBytes bounds how much of a tool's stderr is kept for reporting
+const stderrTailBytes = 4096
+
+// stderrTailLines is the number of stderr lines included in a failed check
+const stderrTailLines = 20
+
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] -- command [args...]",
+	Short: "Run a tool and parse its output",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		parserName, _ := cmd.Flags().GetString("parser")
+		name, _ := cmd.Flags().GetString("name")
+		if code := runExecCommand(viper.GetViper(), parserName, name, args); code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().String("parser", "", fmt.Sprintf("parser for the command output (one of %s)", strings.Join(builtinParserNames(), ", ")))
+	execCmd.Flags().String("name", "", "check name (defaults to the parser name)")
+	execCmd.MarkFlagRequired("parser")
+}
+
+func runExecCommand(vip *viper.Viper, parserName string, name string, args []string) int {
+	configureLogging(vip)
+	pfunc, ok := builtinParsers[parserName]
+	if !ok {
+		logrus.Errorf("Unknown parser %q, expected one of: %s", parserName, strings.Join(builtinParserNames(), ", "))
+		return 2
+	}
+	if name == "" {
+		name = parserName
+	}
+
+	// The check is visible on GitHub while the tool is running
+	vip.Set("mark-in-progress", true)
+
+	runner := parseRunner{
+		environment: newEnvironment(vip),
+		name:        name,
+		parse: commandParser{
+			args:   args,
+			parse:  pfunc,
+			stdout: passthroughOutput(vip),
+			stderr: os.Stderr,
+		},
+	}
+	return runner.run()
+}
+
+// passthroughOutput is where a tool's output is copied to. With --dry-run,
+// stdout is reserved for the check, so it can be piped to e.g. jq.
+func passthroughOutput(c config) io.Writer {
+	if c.GetBool("dry-run") {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// toolError is returned when the tool being run fails without reporting
+// any issues, e.g. because it crashed or was misconfigured
+type toolError struct {
+	command string
+	err     error
+	stderr  string
+}
+
+func (t *toolError) Error() string {
+	message := fmt.Sprintf("%s failed: %s", t.command, t.err)
+	if t.stderr != "" {
+		message = fmt.Sprintf("%s\n\n```\n%s\n```", message, t.stderr)
+	}
+	return message
+}
+
+func (t *toolError) Unwrap() error {
+	return t.err
+}
+
+// commandParser runs a command and parses its output as it's produced,
+// passing the output through so it still shows up in CI logs
+type commandParser struct {
+	args   []string
+	parse  parserFunc
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c commandParser) Run() (parser.Result, error) {
+	command := strings.Join(c.args, " ")
+	stderr := &tailWriter{limit: stderrTailBytes}
+
+	cmd := exec.Command(c.args[0], c.args[1:]...)
+	cmd.Stderr = io.MultiWriter(c.stderr, stderr)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return parser.Result{}, err
+	}
+
+	logrus.WithField("command", command).Debug("Starting command")
+	if err := cmd.Start(); err != nil {
+		return parser.Result{}, &toolError{command: command, err: err}
+	}
+
+	result, parseErr := c.parse(io.TeeReader(stdout, c.stdout)).Run()
+	// Drain anything the parser didn't consume so the command can't block on
+	// a full pipe
+	if _, err := io.Copy(c.stdout, stdout); err != nil {
+		logrus.WithError(err).Warn("Error reading command output")
+	}
+	waitErr := cmd.Wait()
+
+	if waitErr != nil {
+		if parseErr != nil {
+			// A tool that crashed usually didn't print anything parseable, so
+			// its failure is more useful than the parse error
+			err := fmt.Errorf("%v, and its output couldn't be parsed: %w", waitErr, parseErr)
+			return result, &toolError{command: command, err: err, stderr: stderr.lastLines(stderrTailLines)}
+		}
+		// Test runners exit non-zero when tests fail, which parsers report
+		// without annotations if the failures can't be located
+		if len(result.Annotations) == 0 && !result.Failed {
+			return result, &toolError{command: command, err: waitErr, stderr: stderr.lastLines(stderrTailLines)}
+		}
+		logrus.WithError(waitErr).WithField("command", command).Debug("Command exited with error after reporting issues")
+	}
+	if parseErr != nil {
+		return result, parseErr
+	}
+	return result, nil
+}
+
+// tailWriter keeps the last limit bytes written to it
+type tailWriter struct {
+	limit     int
+	buf       []byte
+	truncated bool
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if extra := len(t.buf) - t.limit; extra > 0 {
+		t.buf = t.buf[extra:]
+		t.truncated = true
+	}
+	return len(p), nil
+}
+
+func (t *tailWriter) lastLines(count int) string {
+	output := string(t.buf)
+	if t.truncated {
+		// Skip the partial line left at the start of the buffer
+		if i := strings.Index(output, "\n"); i >= 0 {
+			output = output[i+1:]
+		}
+	}
+	lines := strings.Split(strings.TrimRight(output, "\r\n"), "\n")
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/github"
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func shellParser(t *testing.T, script string, stdout *bytes.Buffer) commandParser {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh unavailable")
+	}
+	return commandParser{
+		args:   []string{"sh", "-c", script},
+		parse:  parser.NewGolinter,
+		stdout: stdout,
+		stderr: &bytes.Buffer{},
+	}
+}
+
+func TestCommandParser_ParsesOutput(t *testing.T) {
+	stdout := bytes.Buffer{}
+	c := shellParser(t, `echo "main.go:12:3: exported function Foo should have comment"`, &stdout)
+
+	result, err := c.Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Annotations))
+	assert.Equal(t, "main.go", result.Annotations[0].Path)
+	assert.Equal(t, 12, result.Annotations[0].Line)
+	assert.Contains(t, stdout.String(), "main.go:12:3", "expected output passed through")
+}
+
+func TestCommandParser_ExitErrorWithIssues(t *testing.T) {
+	c := shellParser(t, `echo "main.go:12:3: message"; exit 1`, &bytes.Buffer{})
+
+	result, err := c.Run()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(result.Annotations))
+}
+
+func TestCommandParser_FailedTestsWithoutLocation(t *testing.T) {
+	script := `echo '{"Action":"run","Package":"example.com/app","Test":"TestRemote"}'
+echo '{"Action":"output","Package":"example.com/app","Test":"TestRemote","Output":"    connection refused\\n"}'
+echo '{"Action":"fail","Package":"example.com/app","Test":"TestRemote","Elapsed":0.1}'
+echo '{"Action":"fail","Package":"example.com/app","Elapsed":0.2}'
+exit 1`
+	c := shellParser(t, script, &bytes.Buffer{})
+	c.parse = parser.NewGoTest
+
+	result, err := c.Run()
+	require.NoError(t, err, "expected failing tests reported rather than a tool error")
+	assert.True(t, result.Failed)
+	assert.Empty(t, result.Annotations)
+	assert.Contains(t, result.Summary, "example.com/app.TestRemote: connection refused")
+}
+
+func TestCommandParser_ToolCrash(t *testing.T) {
+	c := shellParser(t, `echo "something went wrong" >&2; exit 2`, &bytes.Buffer{})
+
+	_, err := c.Run()
+	require.Error(t, err)
+	var toolErr *toolError
+	require.True(t, errors.As(err, &toolErr))
+	assert.Contains(t, err.Error(), "exit status 2")
+	assert.Contains(t, err.Error(), "something went wrong")
+}
+
+func TestCommandParser_ToolCrashWithJSONParser(t *testing.T) {
+	c := shellParser(t, `echo "fatal: no config found" >&2; exit 2`, &bytes.Buffer{})
+	c.parse = parser.NewEslint
+
+	_, err := c.Run()
+	require.Error(t, err)
+	var toolErr *toolError
+	require.True(t, errors.As(err, &toolErr), "expected the crash reported instead of the parse error")
+	assert.Contains(t, err.Error(), "exit status 2")
+	assert.Contains(t, err.Error(), "fatal: no config found")
+	assert.Contains(t, err.Error(), "decode eslint JSON")
+}
+
+func TestCommandParser_ParseErrorAfterSuccess(t *testing.T) {
+	c := shellParser(t, `echo "not json"`, &bytes.Buffer{})
+	c.parse = parser.NewEslint
+
+	_, err := c.Run()
+	require.Error(t, err)
+	var toolErr *toolError
+	assert.False(t, errors.As(err, &toolErr))
+}
+
+func TestCommandParser_MissingCommand(t *testing.T) {
+	c := commandParser{
+		args:   []string{"checkbridge-test-missing-command"},
+		parse:  parser.NewGolinter,
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
+	}
+
+	_, err := c.Run()
+	var toolErr *toolError
+	assert.True(t, errors.As(err, &toolErr))
+}
+
+func TestPassthroughOutput(t *testing.T) {
+	vip := viper.New()
+	assert.Equal(t, os.Stdout, passthroughOutput(vip))
+
+	vip.Set("dry-run", true)
+	assert.Equal(t, os.Stderr, passthroughOutput(vip), "dry run output should be alone on stdout")
+}
+
+func TestTailWriter_KeepsLastLines(t *testing.T) {
+	w := tailWriter{limit: 16}
+	w.Write([]byte("first line\nsecond\n"))
+	w.Write([]byte("third\n"))
+
+	assert.Equal(t, "second\nthird", w.lastLines(5))
+	assert.Equal(t, "third", w.lastLines(1))
+}
+
+func TestRunExec_UnknownParser(t *testing.T) {
+	assert.Equal(t, 2, runExecCommand(viper.New(), "not-a-parser", "", []string{"true"}))
+}
+
+func TestParseRunnerRun_ToolError(t *testing.T) {
+	sc := stubClient{}
+
+	p := parseRunner{
+		environment: stubEnv{
+			environment: newEnvironment(fakeRepoConfig()),
+			sc:          &sc,
+		},
+		name: "golint",
+		parse: stubParser{
+			err: &toolError{command: "golint ./...", err: errors.New("exit status 2"), stderr: "panic: oops"},
+		},
+	}
+
+	assert.Equal(t, 3, p.run())
+	assert.Equal(t, github.CheckConclusionFailure, sc.reportedCheck.Conclusion)
+	assert.Equal(t, "Error running golint", sc.reportedCheck.Output.Title)
+	assert.True(t, strings.Contains(sc.reportedCheck.Output.Summary, "panic: oops"))
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var golangciLintCmd = &cobra.Command{
+	Use:   "golangci-lint",
+	Short: "Parse golangci-lint JSON results (--out-format json)",
+	Run: func(cmd *cobra.Command, args []string) {
+		severity, _ := cmd.Flags().GetString("default-severity")
+		level, err := parser.ParseLevel(severity)
+		if err != nil {
+			configureLogging(viper.GetViper())
+			logrus.WithError(err).Error("Invalid --default-severity")
+			os.Exit(2)
+		}
+		makeCobraCommand("golangci-lint", golangciLintParser(level))(cmd, args)
+	},
+}
+
+// golangciLintParser creates a parserFunc reporting issues without a severity at level
+func golangciLintParser(level parser.Level) parserFunc {
+	return func(input io.Reader) parser.Parser {
+		return parser.NewGolangciLint(level, input)
+	}
+}
+
+func init() {
+	golangciLintCmd.Flags().String("default-severity", string(parser.LevelWarning), "level for issues without a severity (notice, warning or failure)")
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/roverdotcom/checkbridge/parser"
+)
+
+// levelMap maps tool severities (e.g. mypy's "note") or annotation levels to
+// the level annotations should be reported with
+type levelMap map[string]parser.Level
+
+// parseLevelMap parses a level map from comma-separated pairs, e.g.
+// "note=notice,warning=failure"
+func parseLevelMap(value string) (levelMap, error) {
+	m := levelMap{}
+	// Names of the same level, e.g. "warn" and "warning", would make the
+	// level an annotation is mapped to depend on map order
+	levelNames := map[parser.Level]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed level mapping %q, expected severity=level", pair)
+		}
+		level, err := parser.ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if from, err := parser.ParseLevel(name); err == nil {
+			if other, ok := levelNames[from]; ok && other != name {
+				return nil, fmt.Errorf("level mappings %q and %q are both for the %s level", other, name, from)
+			}
+			levelNames[from] = name
+		}
+		m[name] = level
+	}
+	return m, nil
+}
+
+func (m levelMap) level(a parser.Annotation) parser.Level {
+	if a.Severity != "" {
+		if level, ok := m[strings.ToLower(a.Severity)]; ok {
+			return level
+		}
+	}
+	for name, level := range m {
+		if from, err := parser.ParseLevel(name); err == nil && from == a.Level {
+			return level
+		}
+	}
+	return a.Level
+}
+
+func (m levelMap) apply(result parser.Result) parser.Result {
+	if len(m) == 0 {
+		return result
+	}
+	annotations := make([]parser.Annotation, len(result.Annotations))
+	for i, a := range result.Annotations {
+		a.Level = m.level(a)
+		annotations[i] = a
+	}
+	result.Annotations = annotations
+	return result
+}
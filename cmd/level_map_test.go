@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevelMap_OK(t *testing.T) {
+	m, err := parseLevelMap("note=notice, Warning=failure,")
+	require.NoError(t, err)
+	assert.Equal(t, levelMap{
+		"note":    parser.LevelNotice,
+		"warning": parser.LevelError,
+	}, m)
+}
+
+func TestParseLevelMap_Empty(t *testing.T) {
+	m, err := parseLevelMap("")
+	require.NoError(t, err)
+	assert.Empty(t, m)
+}
+
+func TestParseLevelMap_Malformed(t *testing.T) {
+	_, err := parseLevelMap("note")
+	assert.Error(t, err)
+
+	_, err = parseLevelMap("note=catastrophe")
+	assert.Error(t, err)
+}
+
+func TestParseLevelMap_DuplicateLevel(t *testing.T) {
+	_, err := parseLevelMap("warn=notice,warning=failure")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "warning")
+
+	_, err = parseLevelMap("note=failure,notice=warning")
+	assert.Error(t, err)
+
+	m, err := parseLevelMap("warning=notice,WARNING=failure")
+	require.NoError(t, err)
+	assert.Equal(t, levelMap{"warning": parser.LevelError}, m)
+}
+
+func TestLevelMapApply(t *testing.T) {
+	m := levelMap{
+		"note":    parser.LevelNotice,
+		"error":   parser.LevelWarning,
+		"warning": parser.LevelNotice,
+	}
+	result := m.apply(parser.Result{
+		Annotations: []parser.Annotation{
+			{Level: parser.LevelError, Severity: "note"},
+			{Level: parser.LevelError},
+			{Level: parser.LevelWarning},
+			{Level: parser.LevelNotice, Severity: "info"},
+		},
+	})
+
+	levels := []parser.Level{}
+	for _, a := range result.Annotations {
+		levels = append(levels, a.Level)
+	}
+	assert.Equal(t, []parser.Level{
+		parser.LevelNotice,
+		parser.LevelWarning,
+		parser.LevelNotice,
+		parser.LevelNotice,
+	}, levels)
+}
@@ -20,9 +20,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
+	"time"
 	"unicode"
 
 	"github.com/roverdotcom/checkbridge/github"
@@ -35,6 +39,18 @@ import (
 type cobraRunner func(cmd *cobra.Command, args []string)
 type parserFunc func(io.Reader) parser.Parser
 
+// builtinParsers are the parsers that can be selected by name
+var builtinParsers = map[string]parserFunc{
+	"checkstyle":    parser.NewCheckstyle,
+	"eslint":        parser.NewEslint,
+	"golint":        parser.NewGolinter,
+	"gotest":        parser.NewGoTest,
+	"golangci-lint": golangciLintParser(parser.LevelWarning),
+	"junit":         parser.NewJUnit,
+	"mypy":          parser.NewMypy,
+	"sarif":         parser.NewSarif,
+}
+
 var defaultPerms = map[string]string{
 	"checks": "write",
 }
@@ -49,16 +65,40 @@ type parseRunner struct {
 func (p parseRunner) run() int {
 	configureLogging(p.config())
 
+	levels, err := parseLevelMap(p.config().GetString("level-map"))
+	if err != nil {
+		logrus.WithError(err).Error("Invalid level map")
+		return 2
+	}
+	if _, err := p.failThreshold(); err != nil {
+		logrus.WithError(err).Error("Invalid --fail-on level")
+		return 2
+	}
+
+	dryRun := p.config().GetBool("dry-run")
+	if dryRun {
+		if err := checkDryRunFormat(p.config().GetString("dry-run-format")); err != nil {
+			logrus.WithError(err).Error("Invalid --dry-run-format")
+			return 2
+		}
+	}
+
 	repo, err := newRepo(p.config())
 	if err != nil {
-		logrus.WithError(err).Error("Unable to determine repository")
-		return 3
+		if !dryRun {
+			logrus.WithError(err).Error("Unable to determine repository")
+			return 3
+		}
+		logrus.WithError(err).Warn("Unable to determine repository, continuing with dry run")
 	}
 
 	head, err := getHeadSha(p.config())
 	if err != nil {
-		logrus.WithError(err).Error("Unable to read head SHA. Cannot continue.")
-		return 3
+		if !dryRun {
+			logrus.WithError(err).Error("Unable to read head SHA. Cannot continue.")
+			return 3
+		}
+		logrus.WithError(err).Warn("Unable to read head SHA, continuing with dry run")
 	}
 
 	api, err := p.apiClient(repo)
@@ -67,55 +107,77 @@ func (p parseRunner) run() int {
 		return 4
 	}
 
+	started := time.Now()
 	run := github.CheckRun{
 		Status:     github.CheckStatusInProgress,
 		Name:       p.name,
 		HeadSHA:    head,
-		DetailsURL: p.config().GetString("details-url"),
+		DetailsURL: detailsURL(p.config()),
+		StartedAt:  &started,
 	}
 	if p.config().GetBool("mark-in-progress") {
 		logrus.Debug("Marking check as in-progress with GitHub")
-		if err := api.CreateCheck(run); err != nil {
+		if id, err := api.CreateCheck(run); err != nil {
 			logrus.WithError(err).Error("Unable to mark check as in-progress")
+		} else {
+			run.ID = id
 		}
 	}
 
-	run.Status = github.CheckStatusCompleted
-
 	logrus.Debugf("Parsing %s results", p.name)
 
 	result, err := p.parse.Run()
 	if err != nil {
 		errorMessage := fmt.Sprintf("Error parsing %s results", p.name)
+		var toolErr *toolError
+		if errors.As(err, &toolErr) {
+			errorMessage = fmt.Sprintf("Error running %s", p.name)
+		}
 		logrus.WithError(err).Error(errorMessage)
 		run.Conclusion = github.CheckConclusionFailure
 		run.Output.Title = errorMessage
 		run.Output.Summary = err.Error()
 
-		if err := api.CreateCheck(run); err != nil {
+		if err := completeCheck(api, run); err != nil {
 			logrus.WithError(err).Error("Unable to create GitHub check for parse failure")
 		}
 		logrus.Info("Created GitHub check as failure for parse error")
 		return 3
 	}
 
+	result = levels.apply(result)
+	if path := p.config().GetString("baseline"); path != "" {
+		if result, err = p.filterBaseline(result, path); err != nil {
+			logrus.WithError(err).Error("Unable to read baseline, annotating all issues")
+		}
+	}
+	if p.config().GetBool("only-changed") {
+		if result, err = p.filterChanged(result, head); err != nil {
+			logrus.WithError(err).Error("Unable to determine changed lines, annotating all issues")
+		}
+	}
+
 	return p.reportResults(run, result, api)
 }
 
 func (p parseRunner) reportResults(run github.CheckRun, result parser.Result, api github.CheckClient) int {
 	run.Output = result
 	summary := summarizeResult(result)
-	if run.Output.Summary == "" {
-		run.Output.Summary = fmt.Sprintf("%s found %s", p.name, summary)
+	counts := fmt.Sprintf("%s found %s", p.name, summary)
+	if result.Failed && len(result.Annotations) == 0 {
+		// "found no issues" would contradict the failure the parser describes
+		counts = ""
 	}
+	// Anything in the parser's summary is kept as details after the counts
+	run.Output.Summary = appendSummary(counts, result.Summary)
 	if run.Output.Title == "" {
 		run.Output.Title = capitalizeFirstChar(summary)
 	}
 
-	if len(run.Output.Annotations) == 0 {
+	if len(run.Output.Annotations) == 0 && !result.Failed {
 		logrus.Infof("No violations reported from %s", p.name)
 		run.Conclusion = github.CheckConclusionSuccess
-		if err := api.CreateCheck(run); err != nil {
+		if err := completeCheck(api, run); err != nil {
 			logrus.WithError(err).Error("Unable to create GitHub check")
 			return 5
 		}
@@ -125,17 +187,28 @@ func (p parseRunner) reportResults(run github.CheckRun, result parser.Result, ap
 
 	logrus.Infof("Got %d annotations", len(result.Annotations))
 
-	if p.config().GetBool("annotate-only") {
+	threshold, err := p.failThreshold()
+	if err != nil {
+		logrus.WithError(err).Error("Invalid --fail-on level")
+		return 2
+	}
+	failing := hasFailingAnnotations(result, threshold) || (result.Failed && threshold != "")
+	if p.config().GetBool("annotate-only") || !failing {
 		run.Conclusion = github.CheckConclusionNeutral
 	} else {
 		run.Conclusion = github.CheckConclusionFailure
 	}
 
-	if err := api.CreateCheck(run); err != nil {
+	if err := completeCheck(api, run); err != nil {
 		logrus.WithError(err).Error("Unable to create GitHub check")
 		return 5
 	}
 
+	if !failing {
+		logrus.Debugf("No issues at or above --fail-on level %q, exiting 0", p.config().GetString("fail-on"))
+		return 0
+	}
+
 	if !p.config().GetBool("exit-zero") {
 		logrus.Info("Exiting 1 due to issues found by tool. Pass --exit-zero to disable this behavior")
 		// Exit non-zero to mark the result of the pipeline as failed since the tool found issues with the code
@@ -146,6 +219,21 @@ func (p parseRunner) reportResults(run github.CheckRun, result parser.Result, ap
 	return 0
 }
 
+// completeCheck marks the run as completed and sends it to GitHub, updating
+// the in-progress check run if one was created
+func completeCheck(api github.CheckClient, run github.CheckRun) error {
+	completed := time.Now()
+	run.Status = github.CheckStatusCompleted
+	run.CompletedAt = &completed
+
+	if run.ID != 0 {
+		logrus.WithField("id", run.ID).Debug("Updating in-progress check run")
+		return api.UpdateCheck(run)
+	}
+	_, err := api.CreateCheck(run)
+	return err
+}
+
 func makeCobraCommand(name string, pfunc parserFunc) cobraRunner {
 	return func(cmd *cobra.Command, args []string) {
 		v := viper.GetViper()
@@ -163,6 +251,26 @@ func makeCobraCommand(name string, pfunc parserFunc) cobraRunner {
 	}
 }
 
+func builtinParserNames() []string {
+	names := []string{}
+	for name := range builtinParsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appendSummary adds a paragraph to a check summary
+func appendSummary(summary string, paragraph string) string {
+	if summary == "" {
+		return paragraph
+	}
+	if paragraph == "" {
+		return summary
+	}
+	return summary + "\n\n" + paragraph
+}
+
 func capitalizeFirstChar(str string) string {
 	for i, v := range str {
 		return string(unicode.ToUpper(v)) + str[i+1:]
@@ -170,31 +278,76 @@ func capitalizeFirstChar(str string) string {
 	return ""
 }
 
+// failThreshold is the lowest annotation level that fails the check, or an
+// empty level if the check should never fail
+func (p parseRunner) failThreshold() (parser.Level, error) {
+	switch failOn := p.config().GetString("fail-on"); failOn {
+	case "":
+		return parser.LevelWarning, nil
+	case "never":
+		return "", nil
+	default:
+		return parser.ParseLevel(failOn)
+	}
+}
+
+// levelRank orders annotation levels by severity, treating unknown levels as
+// errors
+func levelRank(level parser.Level) int {
+	switch level {
+	case parser.LevelNotice:
+		return 1
+	case parser.LevelWarning:
+		return 2
+	}
+	return 3
+}
+
+func hasFailingAnnotations(result parser.Result, threshold parser.Level) bool {
+	if threshold == "" {
+		return false
+	}
+	for _, a := range result.Annotations {
+		if levelRank(a.Level) >= levelRank(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
 func summarizeResult(result parser.Result) string {
 	errorCount := 0
 	warningCount := 0
-	summary := "no issues"
+	noticeCount := 0
 
 	for _, a := range result.Annotations {
 		if a.Level == parser.LevelWarning {
 			warningCount++
 		} else if a.Level == parser.LevelError {
 			errorCount++
+		} else if a.Level == parser.LevelNotice {
+			noticeCount++
 		}
 	}
+
+	counts := []string{}
 	if errorCount > 0 {
-		summary = fmt.Sprintf("%d %s", errorCount, pluralize("error", errorCount))
+		counts = append(counts, fmt.Sprintf("%d %s", errorCount, pluralize("error", errorCount)))
 	}
 	if warningCount > 0 {
-		warningMessage := fmt.Sprintf("%d %s", warningCount, pluralize("warning", warningCount))
-		if errorCount > 0 {
-			summary = fmt.Sprintf("%s and %s", summary, warningMessage)
-		} else {
-			summary = warningMessage
-		}
+		counts = append(counts, fmt.Sprintf("%d %s", warningCount, pluralize("warning", warningCount)))
+	}
+	if noticeCount > 0 {
+		counts = append(counts, fmt.Sprintf("%d %s", noticeCount, pluralize("notice", noticeCount)))
 	}
 
-	return summary
+	switch len(counts) {
+	case 0:
+		return "no issues"
+	case 1:
+		return counts[0]
+	}
+	return fmt.Sprintf("%s and %s", strings.Join(counts[:len(counts)-1], ", "), counts[len(counts)-1])
 }
 
 func pluralize(noun string, count int) string {
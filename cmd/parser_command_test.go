@@ -32,11 +32,25 @@ import (
 
 type stubClient struct {
 	reportedCheck *github.CheckRun
+	createCount   int
+	updateCount   int
 	err           *error
 }
 
-func (s *stubClient) CreateCheck(check github.CheckRun) error {
+const stubCheckID = 42
+
+func (s *stubClient) CreateCheck(check github.CheckRun) (int64, error) {
+	s.reportedCheck = &check
+	s.createCount++
+	if s.err != nil {
+		return 0, *s.err
+	}
+	return stubCheckID, nil
+}
+
+func (s *stubClient) UpdateCheck(check github.CheckRun) error {
 	s.reportedCheck = &check
+	s.updateCount++
 	if s.err != nil {
 		return *s.err
 	}
@@ -158,6 +172,51 @@ func TestParseRunnerRun_ErrorParsingResult(t *testing.T) {
 	assert.Equal(t, "can't parse this", sc.reportedCheck.Output.Summary)
 }
 
+func TestParseRunnerRun_UpdatesInProgressCheck(t *testing.T) {
+	vip := fakeRepoConfig()
+
+	sc := stubClient{}
+
+	p := parseRunner{
+		environment: stubEnv{
+			environment: newEnvironment(vip),
+			sc:          &sc,
+		},
+		parse: stubParser{},
+	}
+
+	assert.Equal(t, 0, p.run())
+	assert.Equal(t, 1, sc.createCount, "expected a single check run created")
+	assert.Equal(t, 1, sc.updateCount, "expected in-progress check run updated")
+
+	check := sc.reportedCheck
+	assert.Equal(t, int64(stubCheckID), check.ID)
+	assert.Equal(t, github.CheckStatusCompleted, check.Status)
+	assert.Equal(t, github.CheckConclusionSuccess, check.Conclusion)
+	assert.NotNil(t, check.StartedAt)
+	assert.NotNil(t, check.CompletedAt)
+}
+
+func TestParseRunnerRun_NotMarkedInProgress(t *testing.T) {
+	vip := fakeRepoConfig().(*viper.Viper)
+	vip.Set("mark-in-progress", false)
+
+	sc := stubClient{}
+
+	p := parseRunner{
+		environment: stubEnv{
+			environment: newEnvironment(vip),
+			sc:          &sc,
+		},
+		parse: stubParser{},
+	}
+
+	assert.Equal(t, 0, p.run())
+	assert.Equal(t, 1, sc.createCount)
+	assert.Equal(t, 0, sc.updateCount)
+	assert.Equal(t, github.CheckStatusCompleted, sc.reportedCheck.Status)
+}
+
 func TestReportResults_NoViolations(t *testing.T) {
 	api := &stubClient{}
 	result := parser.Result{}
@@ -214,6 +273,146 @@ func TestReportResults_WithViolations_AnnotateOnly(t *testing.T) {
 	assert.Equal(t, github.CheckConclusionNeutral, api.reportedCheck.Conclusion)
 }
 
+func TestReportResults_OnlyNotices(t *testing.T) {
+	api := &stubClient{}
+	result := parser.Result{
+		Annotations: []parser.Annotation{{Level: parser.LevelNotice}},
+	}
+	p := parseRunner{
+		environment: newEnvironment(viper.New()),
+	}
+	code := p.reportResults(github.CheckRun{}, result, api)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, github.CheckConclusionNeutral, api.reportedCheck.Conclusion)
+}
+
+func TestReportResults_FailOn(t *testing.T) {
+	warnings := parser.Result{
+		Annotations: []parser.Annotation{{Level: parser.LevelWarning}},
+	}
+	withErrors := parser.Result{
+		Annotations: []parser.Annotation{{Level: parser.LevelWarning}, {Level: parser.LevelError}},
+	}
+	notices := parser.Result{
+		Annotations: []parser.Annotation{{Level: parser.LevelNotice}},
+	}
+
+	cases := []struct {
+		failOn     string
+		result     parser.Result
+		code       int
+		conclusion github.CheckConclusion
+	}{
+		{"error", warnings, 0, github.CheckConclusionNeutral},
+		{"error", withErrors, 1, github.CheckConclusionFailure},
+		{"warning", warnings, 1, github.CheckConclusionFailure},
+		{"warning", notices, 0, github.CheckConclusionNeutral},
+		{"notice", notices, 1, github.CheckConclusionFailure},
+		{"never", withErrors, 0, github.CheckConclusionNeutral},
+	}
+
+	for _, c := range cases {
+		vip := viper.New()
+		vip.Set("fail-on", c.failOn)
+		api := &stubClient{}
+		p := parseRunner{
+			environment: newEnvironment(vip),
+		}
+		code := p.reportResults(github.CheckRun{}, c.result, api)
+
+		assert.Equal(t, c.code, code, "fail-on=%s", c.failOn)
+		assert.Equal(t, c.conclusion, api.reportedCheck.Conclusion, "fail-on=%s", c.failOn)
+	}
+}
+
+func TestReportResults_FailedWithoutAnnotations(t *testing.T) {
+	result := parser.Result{
+		Title:   "1 of 3 tests failed",
+		Summary: "TestCreate failed",
+		Failed:  true,
+	}
+
+	api := &stubClient{}
+	p := parseRunner{
+		environment: newEnvironment(viper.New()),
+		name:        "junit",
+	}
+	code := p.reportResults(github.CheckRun{}, result, api)
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, github.CheckConclusionFailure, api.reportedCheck.Conclusion)
+	assert.Equal(t, "1 of 3 tests failed", api.reportedCheck.Output.Title)
+	assert.Equal(t, "TestCreate failed", api.reportedCheck.Output.Summary)
+
+	vip := viper.New()
+	vip.Set("fail-on", "never")
+	api = &stubClient{}
+	p.environment = newEnvironment(vip)
+	code = p.reportResults(github.CheckRun{}, result, api)
+
+	assert.Equal(t, 0, code)
+	assert.Equal(t, github.CheckConclusionNeutral, api.reportedCheck.Conclusion)
+}
+
+func TestParseRunnerRun_InvalidFailOn(t *testing.T) {
+	vip := viper.New()
+	vip.Set("fail-on", "sometimes")
+
+	p := parseRunner{
+		environment: newEnvironment(vip),
+	}
+	assert.Equal(t, 2, p.run())
+}
+
+func TestParseRunnerRun_LevelMap(t *testing.T) {
+	vip := fakeRepoConfig().(*viper.Viper)
+	vip.Set("level-map", "note=notice")
+
+	sc := stubClient{}
+
+	p := parseRunner{
+		environment: stubEnv{
+			environment: newEnvironment(vip),
+			sc:          &sc,
+		},
+		parse: stubParser{
+			r: parser.Result{
+				Annotations: []parser.Annotation{{Level: parser.LevelError, Severity: "note"}},
+			},
+		},
+	}
+
+	assert.Equal(t, 0, p.run())
+	assert.Equal(t, parser.LevelNotice, sc.reportedCheck.Output.Annotations[0].Level)
+	assert.Equal(t, github.CheckConclusionNeutral, sc.reportedCheck.Conclusion)
+}
+
+func TestParseRunnerRun_InvalidLevelMap(t *testing.T) {
+	vip := viper.New()
+	vip.Set("level-map", "note")
+
+	p := parseRunner{
+		environment: newEnvironment(vip),
+	}
+	assert.Equal(t, 2, p.run())
+}
+
+func TestReportResults_SummaryDetails(t *testing.T) {
+	api := &stubClient{}
+	result := parser.Result{
+		Annotations: []parser.Annotation{{Level: parser.LevelWarning}},
+		Summary:     "Some details",
+	}
+	p := parseRunner{
+		environment: newEnvironment(viper.New()),
+		name:        "golint",
+	}
+	p.reportResults(github.CheckRun{}, result, api)
+
+	assert.Equal(t, "golint found 1 warning\n\nSome details", api.reportedCheck.Output.Summary)
+}
+
 func TestReportResults_GitHubError(t *testing.T) {
 	err := errors.New("unicorns")
 	api := &stubClient{
@@ -263,6 +462,18 @@ func TestSummaryResult_TwoWarnings(t *testing.T) {
 	assert.Equal(t, "2 warnings", summarizeResult(result))
 }
 
+func TestSummaryResult_WithNotices(t *testing.T) {
+	result := parser.Result{
+		Annotations: []parser.Annotation{
+			{Level: parser.LevelError},
+			{Level: parser.LevelWarning},
+			{Level: parser.LevelNotice},
+			{Level: parser.LevelNotice},
+		},
+	}
+	assert.Equal(t, "1 error, 1 warning and 2 notices", summarizeResult(result))
+}
+
 func TestCapitalizeFirstChar_TwoWords(t *testing.T) {
 	assert.Equal(t, "No issues", capitalizeFirstChar("no issues"))
 }
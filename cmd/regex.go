@@ -48,22 +48,33 @@ var regexCmd = &cobra.Command{
 
 func runRegexCommand(vip *viper.Viper, stdin io.Reader) int {
 	configureLogging(vip)
-	regex, err := regexp.Compile(vip.GetString("regex"))
+	pfunc, err := regexParserFunc(vip)
 	if err != nil {
 		logrus.WithError(err).Error("Unable to compile regular expression")
 		return 2
 	}
-	extractor := makeExtractor(vip)
-	parse := parser.NewRegexer(regex, extractor, stdin)
 
 	runner := parseRunner{
 		environment: newEnvironment(vip),
 		name:        vip.GetString("name"),
-		parse:       parse,
+		parse:       pfunc(stdin),
 	}
 	return runner.run()
 }
 
+// regexParserFunc creates a parserFunc from the configured regex and positions
+func regexParserFunc(vip *viper.Viper) (parserFunc, error) {
+	regex, err := regexp.Compile(vip.GetString("regex"))
+	if err != nil {
+		return nil, err
+	}
+	extractor := makeExtractor(vip)
+
+	return func(input io.Reader) parser.Parser {
+		return parser.NewRegexer(regex, extractor, input)
+	}, nil
+}
+
 func init() {
 	regexCmd.Flags().Bool("warn", false, "treat regex matches as warning (instead of error)")
 	regexCmd.Flags().String("name", "", "check name (required)")
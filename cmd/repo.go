@@ -23,7 +23,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"os/exec"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -43,12 +43,13 @@ func (r repo) Owner() string {
 	return r.owner
 }
 
-var githubRepoRegex = regexp.MustCompile("git@github.com:(.+)/(.+)")
+// scpRemoteRegex matches scp-like git remotes, e.g. git@github.com:owner/name.git
+var scpRemoteRegex = regexp.MustCompile(`^(?:[^@/]+@)?[^:/]+:([^/].*)$`)
 
 func repoFromPath(path string) (repo, error) {
 	repoParts := strings.Split(path, "/")
-	if len(repoParts) != 2 {
-		return repo{}, fmt.Errorf("malformed GITHUB_REPOSITORY: %s", path)
+	if len(repoParts) != 2 || repoParts[0] == "" || repoParts[1] == "" {
+		return repo{}, fmt.Errorf("malformed repository %q, expected owner/name", path)
 	}
 	return repo{
 		owner: repoParts[0],
@@ -56,6 +57,30 @@ func repoFromPath(path string) (repo, error) {
 	}, nil
 }
 
+// parseRepo reads a repository from either "owner/name" or the URL of a git
+// remote on GitHub or GitHub Enterprise Server, e.g.
+// git@github.com:owner/name.git, ssh://git@github.com/owner/name.git or
+// https://github.example.com/owner/name
+func parseRepo(remote string) (repo, error) {
+	var path string
+	if strings.Contains(remote, "://") {
+		parsed, err := url.Parse(remote)
+		if err != nil {
+			return repo{}, fmt.Errorf("malformed repository URL: %w", err)
+		}
+		path = parsed.Path
+	} else if match := scpRemoteRegex.FindStringSubmatch(remote); match != nil {
+		path = match[1]
+	} else if strings.Contains(remote, ":") {
+		return repo{}, fmt.Errorf("malformed repository URL %q", remote)
+	} else {
+		path = remote
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return repoFromPath(path)
+}
+
 func newRepo(c config) (repo, error) {
 	passedRepo := c.GetString("github-repo")
 	if passedRepo != "" {
@@ -63,16 +88,18 @@ func newRepo(c config) (repo, error) {
 		return repoFromPath(passedRepo)
 	}
 
-	bkRepo := c.GetString("buildkite-repo")
-	if bkRepo != "" {
-		logrus.WithField("repo", bkRepo).Debug("Using BUILDKITE_REPO environment value")
-		match := githubRepoRegex.FindStringSubmatch(bkRepo)
-		if match != nil {
-			r := strings.TrimRight(match[2], ".git")
-			return repo{
-				owner: match[1],
-				name:  r,
-			}, nil
+	if ci := detectCI(); ci.repo != "" {
+		logrus.WithField("repo", ci.repo).WithField("provider", ci.provider).Debug("Using repo from CI environment")
+		return parseRepo(ci.repo)
+	}
+
+	if remote := c.GetString("git-remote"); remote != "" {
+		remoteURL, err := runGit("remote", "get-url", remote)
+		if err != nil {
+			logrus.WithError(err).Debug("Unable to get repo from git remote")
+		} else {
+			logrus.WithField("remote", remote).WithField("url", remoteURL).Debug("Using repo from git remote")
+			return parseRepo(remoteURL)
 		}
 	}
 
@@ -85,10 +112,18 @@ func getHeadSha(c config) (string, error) {
 		logrus.WithField("sha", passedSha).Debug("Using configured SHA")
 		return passedSha, nil
 	}
-	cmd := exec.Command("git", "rev-parse", "HEAD")
-	out, err := cmd.Output()
-	if err != nil {
-		return "", err
+	if ci := detectCI(); ci.sha != "" {
+		logrus.WithField("sha", ci.sha).WithField("provider", ci.provider).Debug("Using SHA from CI environment")
+		return ci.sha, nil
+	}
+	logrus.Debug("No configured or CI SHA, using git HEAD")
+	return runGit("rev-parse", "HEAD")
+}
+
+// detailsURL returns the URL linked from checks, defaulting to the CI build
+func detailsURL(c config) string {
+	if url := c.GetString("details-url"); url != "" {
+		return url
 	}
-	return strings.TrimSpace(string(out)), nil
+	return detectCI().detailsURL
 }
@@ -21,6 +21,7 @@
 package cmd
 
 import (
+	"os"
 	"os/exec"
 	"testing"
 
@@ -47,25 +48,31 @@ func TestNewRepo_FromGithub(t *testing.T) {
 }
 
 func TestNewRepo_FromBuildKite(t *testing.T) {
-	vip := viper.New()
-	vip.Set("buildkite-repo", "git@github.com:org/with-dashes.git")
-	repo, err := newRepo(vip)
+	defer withEnv(map[string]string{
+		"BUILDKITE":      "true",
+		"BUILDKITE_REPO": "git@github.com:org/with-dashes.git",
+	})()
+	repo, err := newRepo(viper.New())
 	require.NoError(t, err)
 	assert.Equal(t, "org", repo.owner)
 	assert.Equal(t, "with-dashes", repo.name)
 }
 
 func TestNewRepo_MalformedBK(t *testing.T) {
-	vip := viper.New()
-	vip.Set("buildkite-repo", "ssh://github.com:org|with-dashes.git")
-	_, err := newRepo(vip)
+	defer withEnv(map[string]string{
+		"BUILDKITE":      "true",
+		"BUILDKITE_REPO": "ssh://github.com:org|with-dashes.git",
+	})()
+	_, err := newRepo(viper.New())
 	assert.Error(t, err)
 }
 
 func TestNewRepo_EmptyBK(t *testing.T) {
-	vip := viper.New()
-	vip.Set("buildkite-repo", "")
-	_, err := newRepo(vip)
+	defer withEnv(map[string]string{
+		"BUILDKITE":      "true",
+		"BUILDKITE_REPO": "",
+	})()
+	_, err := newRepo(viper.New())
 	assert.Error(t, err)
 }
 
@@ -97,3 +104,121 @@ func TestGetHeadSha_FromExec(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEmpty(t, sha)
 }
+
+func TestNewRepo_FromCI(t *testing.T) {
+	defer withEnv(map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_REPOSITORY": "org/repo",
+	})()
+	repo, err := newRepo(viper.New())
+	require.NoError(t, err)
+	assert.Equal(t, "org", repo.owner)
+	assert.Equal(t, "repo", repo.name)
+
+	vip := viper.New()
+	vip.Set("github-repo", "foo/bar")
+	repo, err = newRepo(vip)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", repo.owner)
+}
+
+func TestGetHeadSha_FromCI(t *testing.T) {
+	defer withEnv(map[string]string{
+		"DRONE":            "true",
+		"DRONE_COMMIT_SHA": "ci-sha",
+	})()
+	sha, err := getHeadSha(viper.New())
+	require.NoError(t, err)
+	assert.Equal(t, "ci-sha", sha)
+
+	vip := viper.New()
+	vip.Set("commit-sha", "my-sha")
+	sha, err = getHeadSha(vip)
+	require.NoError(t, err)
+	assert.Equal(t, "my-sha", sha)
+}
+
+func TestGetHeadSha_BuildkiteHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git unavailable")
+	}
+	defer withEnv(map[string]string{
+		"BUILDKITE":        "true",
+		"BUILDKITE_COMMIT": "HEAD",
+	})()
+	sha, err := getHeadSha(viper.New())
+	require.NoError(t, err)
+	assert.Equal(t, git(t, "rev-parse", "HEAD"), sha)
+}
+
+func TestDetailsURL(t *testing.T) {
+	defer withEnv(map[string]string{
+		"CIRCLECI":         "true",
+		"CIRCLE_BUILD_URL": "https://circleci.com/gh/org/repo/1",
+	})()
+	assert.Equal(t, "https://circleci.com/gh/org/repo/1", detailsURL(viper.New()))
+
+	vip := viper.New()
+	vip.Set("details-url", "https://example.com")
+	assert.Equal(t, "https://example.com", detailsURL(vip))
+}
+
+func TestParseRepo(t *testing.T) {
+	valid := map[string]string{
+		"owner/widget":                                    "owner/widget",
+		"git@github.com:owner/widget.git":                 "owner/widget",
+		"git@github.com:owner/widget":                     "owner/widget",
+		"github.com:owner/widget.git":                     "owner/widget",
+		"ssh://git@github.com/owner/widget.git":           "owner/widget",
+		"ssh://git@github.example.com:2222/owner/widget":  "owner/widget",
+		"https://github.com/owner/widget.git":             "owner/widget",
+		"https://github.com/owner/widget/":                "owner/widget",
+		"https://token@github.example.com/owner/with.dot": "owner/with.dot",
+		"git@github.example.com:owner/tig.git":            "owner/tig",
+	}
+	for remote, expected := range valid {
+		r, err := parseRepo(remote)
+		if assert.NoError(t, err, remote) {
+			assert.Equal(t, expected, r.Owner()+"/"+r.Name(), remote)
+		}
+	}
+
+	invalid := []string{
+		"widget.git",
+		"https://github.com/owner",
+		"https://github.com/owner/widget/pulls",
+		"ssh://github.com:org|with-dashes.git",
+		"git@github.com:/owner",
+	}
+	for _, remote := range invalid {
+		_, err := parseRepo(remote)
+		assert.Error(t, err, remote)
+	}
+}
+
+func TestNewRepo_FromGitRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git unavailable")
+	}
+	withTempDir(t, func(dir string) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer os.Chdir(wd)
+
+		git(t, "init", "-q")
+		git(t, "remote", "add", "upstream", "https://github.com/org/widget.git")
+
+		vip := viper.New()
+		vip.Set("git-remote", "upstream")
+		repo, err := newRepo(vip)
+		require.NoError(t, err)
+		assert.Equal(t, "org", repo.owner)
+		assert.Equal(t, "widget", repo.name)
+
+		vip.Set("git-remote", "checkbridge-test-missing-remote")
+		_, err = newRepo(vip)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing repository")
+	})
+}
@@ -20,9 +20,11 @@
 package cmd
 
 import (
+	"errors"
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -54,6 +56,11 @@ func getInput(c config) (io.ReadCloser, error) {
 	if path := c.GetString("file"); path != "" {
 		return os.Open(path)
 	}
+	// The key would be read from the tool's output, and the output lost
+	if c.GetString("private-key") == "-" {
+		return nil, errors.New("--private-key - can't be used when the tool's output is read from stdin, " +
+			"pass the output with --file or run the tool with 'checkbridge exec'")
+	}
 
 	return os.Stdin, nil
 }
@@ -76,20 +83,34 @@ func init() {
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolP("exit-zero", "z", false, "exit zero even when tool reports issues")
 	rootCmd.PersistentFlags().BoolP("annotate-only", "o", false, "only leave annotations, never mark check as failed")
+	rootCmd.PersistentFlags().String("fail-on", "warning", "lowest annotation level that fails the check (error, warning, notice or never)")
 	rootCmd.PersistentFlags().BoolP("mark-in-progress", "m", false, "mark check as in progress before parsing")
 	rootCmd.PersistentFlags().StringP("details-url", "d", "", "details URL to send for check")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "print the check instead of sending it to GitHub (no credentials needed)")
+	rootCmd.PersistentFlags().String("dry-run-format", "json", "output format for --dry-run (json or table)")
+	rootCmd.PersistentFlags().Bool("only-changed", false, "only annotate lines changed since the base ref")
+	rootCmd.PersistentFlags().String("base-ref", "", "base ref for --only-changed (defaults to the pull request's base branch or origin's default branch)")
+	rootCmd.PersistentFlags().Bool("count-unchanged", false, "with --only-changed, count issues outside of the changed lines in the summary")
+	rootCmd.PersistentFlags().String("baseline", "", "baseline file of known issues which are not annotated")
+	rootCmd.PersistentFlags().String("level-map", "", "map tool severities or levels to annotation levels (e.g. 'note=notice,warning=failure')")
 
 	// Parser configuration
 	rootCmd.PersistentFlags().StringP("file", "f", "", "read input from named file instead of stdin")
+	rootCmd.PersistentFlags().String("config", "", "configuration file (default .checkbridge.yml)")
 
 	// Authentication configuration flags
 	rootCmd.PersistentFlags().IntP("application-id", "a", 0, "GitHub application ID (numeric)")
 	rootCmd.PersistentFlags().IntP("installation-id", "i", 0, "GitHub installation ID (numeric)")
-	rootCmd.PersistentFlags().StringP("private-key", "p", "", "GitHub application private key path or value")
+	rootCmd.PersistentFlags().StringP("private-key", "p", "", "GitHub application private key path or value, '-' to read it from stdin or 'env:NAME' for an environment variable")
 	rootCmd.PersistentFlags().StringP("github-token", "t", "", "short-lived GitHub app token for checks auth")
+	rootCmd.PersistentFlags().String("token-cache", "", "file to cache GitHub app tokens in between runs")
+	rootCmd.PersistentFlags().Duration("github-timeout", 30*time.Second, "timeout for each GitHub API request")
+	rootCmd.PersistentFlags().Int("github-retries", 3, "number of times to retry failed or rate limited GitHub API requests")
+	rootCmd.PersistentFlags().String("github-api-url", "", "GitHub API URL, for GitHub Enterprise Server (default https://api.github.com)")
 
 	rootCmd.PersistentFlags().StringP("github-repo", "r", "", "GitHub repository (e.g. 'roverdotcom/checkbridge')")
 	rootCmd.PersistentFlags().StringP("commit-sha", "c", "", "commit SHA to report status checks for")
+	rootCmd.PersistentFlags().String("git-remote", "origin", "git remote to read the GitHub repository from, when not set or detected from CI")
 
 	viper.BindPFlags(rootCmd.PersistentFlags())
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
@@ -97,23 +118,46 @@ func init() {
 	// Additional environment variables
 	// Allow $GITHUB_TOKEN by convention
 	viper.BindEnv("github-token", "GITHUB_TOKEN")
-	// Allow $GITHUB_REPOSITORY for GitHub actions
-	viper.BindEnv("github-repo", "GITHUB_REPOSITORY")
-	viper.BindEnv("buildkite-repo", "BUILDKITE_REPO")
-	viper.BindEnv("details-url", "BUILDKITE_BUILD_URL")
-	viper.BindEnv("commit-sha", "GITHUB_SHA")
-	viper.BindEnv("commit-sha", "BUILDKITE_COMMIT")
+	// Allow $GITHUB_API_URL, set by GitHub actions on Enterprise Server too
+	viper.BindEnv("github-api-url", "GITHUB_API_URL")
+	// The repo, SHA, details URL and base ref also default to values from
+	// the CI environment, see detectCI
 
 	// Sub-command registration
 	rootCmd.AddCommand(golintCmd)
 	rootCmd.AddCommand(mypyCmd)
 	rootCmd.AddCommand(authCheckCommand)
+	rootCmd.AddCommand(tokenCmd)
 	rootCmd.AddCommand(regexCmd)
+	rootCmd.AddCommand(sarifCmd)
+	rootCmd.AddCommand(eslintCmd)
+	rootCmd.AddCommand(golangciLintCmd)
+	rootCmd.AddCommand(checkstyleCmd)
+	rootCmd.AddCommand(junitCmd)
+	rootCmd.AddCommand(gotestCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(baselineCmd)
 }
 
 func initConfig() {
 	viper.AutomaticEnv()
+
+	if path := viper.GetString("config"); path != "" {
+		viper.SetConfigFile(path)
+	} else {
+		viper.SetConfigName(".checkbridge")
+		viper.AddConfigPath(".")
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			logrus.WithError(err).Error("Unable to read configuration file")
+			os.Exit(2)
+		}
+		return
+	}
+	logrus.WithField("path", viper.ConfigFileUsed()).Debug("Read configuration file")
 }
 
 // Execute is the entrypoint of the application
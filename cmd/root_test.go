@@ -54,3 +54,16 @@ func TestGetInput_Stdin(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, in, os.Stdin)
 }
+
+func TestGetInput_StdinPrivateKey(t *testing.T) {
+	vip := viper.New()
+	vip.Set("private-key", "-")
+	_, err := getInput(vip)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--file")
+
+	vip.Set("file", "root_test.go")
+	in, err := getInput(vip)
+	require.NoError(t, err)
+	in.Close()
+}
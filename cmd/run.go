@@ -0,0 +1,219 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run check-name [-- command [args...]]",
+	Short: "Run a check defined in the configuration file",
+	Long: `Run a check defined under "checks" in the configuration file.
+
+Results are read from stdin (or --file), unless a command is given, in which
+case it's run and its output parsed as with "checkbridge exec".`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if code := runConfiguredCheck(viper.GetViper(), cmd.Flags(), args[0], args[1:]); code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func runConfiguredCheck(vip *viper.Viper, flags *pflag.FlagSet, name string, command []string) int {
+	configureLogging(vip)
+	check, err := loadCheck(vip, flags, name)
+	if err != nil {
+		logrus.WithError(err).Errorf("Unable to load check %s", name)
+		return 2
+	}
+
+	var parse parser.Parser
+	if len(command) > 0 {
+		check.config.Set("mark-in-progress", true)
+		parse = commandParser{
+			args:   command,
+			parse:  check.parse,
+			stdout: passthroughOutput(check.config),
+			stderr: os.Stderr,
+		}
+	} else {
+		input := mustGetInput(check.config)
+		defer input.Close()
+		parse = check.parse(input)
+	}
+
+	runner := parseRunner{
+		environment: newEnvironment(check.config),
+		name:        check.name,
+		parse:       parse,
+	}
+	return runner.run()
+}
+
+// configuredCheck is a check defined in the configuration file
+type configuredCheck struct {
+	name   string
+	config *viper.Viper
+	parse  parserFunc
+}
+
+// loadCheck reads the named check from the configuration file. Settings in
+// the check take precedence over global settings, but not over flags passed
+// on the command line.
+func loadCheck(vip *viper.Viper, flags *pflag.FlagSet, name string) (configuredCheck, error) {
+	checkConfig := vip.Sub("checks." + name)
+	if checkConfig == nil {
+		return configuredCheck{}, fmt.Errorf("no check named %q in configuration", name)
+	}
+
+	merged := viper.New()
+	for _, key := range vip.AllKeys() {
+		merged.Set(key, vip.Get(key))
+	}
+	for _, key := range checkConfig.AllKeys() {
+		if flags != nil {
+			if flag := flags.Lookup(key); flag != nil && flag.Changed {
+				continue
+			}
+		}
+		merged.Set(key, checkConfig.Get(key))
+	}
+
+	check := configuredCheck{
+		name:   name,
+		config: merged,
+	}
+	if checkName := checkConfig.GetString("name"); checkName != "" {
+		check.name = checkName
+	}
+
+	parserName := checkConfig.GetString("parser")
+	var pfunc parserFunc
+	if parserName == "regex" {
+		regexFunc, err := regexParserFunc(merged)
+		if err != nil {
+			return configuredCheck{}, fmt.Errorf("compile regex: %w", err)
+		}
+		pfunc = regexFunc
+	} else if builtin, ok := builtinParsers[parserName]; ok {
+		pfunc = builtin
+	} else {
+		return configuredCheck{}, fmt.Errorf("unknown parser %q, expected regex or one of: %s", parserName, strings.Join(builtinParserNames(), ", "))
+	}
+
+	filter := checkFilter{
+		paths:        checkConfig.GetStringSlice("paths"),
+		excludePaths: checkConfig.GetStringSlice("exclude-paths"),
+	}
+	if levelName := checkConfig.GetString("level"); levelName != "" {
+		level, err := parser.ParseLevel(levelName)
+		if err != nil {
+			return configuredCheck{}, err
+		}
+		filter.level = level
+	}
+	check.parse = filter.wrap(pfunc)
+
+	return check, nil
+}
+
+// checkFilter applies a configured check's path filters and level to the
+// annotations from its parser
+type checkFilter struct {
+	paths        []string
+	excludePaths []string
+	level        parser.Level
+}
+
+func (f checkFilter) wrap(pfunc parserFunc) parserFunc {
+	return func(input io.Reader) parser.Parser {
+		return filteredParser{
+			filter: f,
+			parse:  pfunc(input),
+		}
+	}
+}
+
+func (f checkFilter) apply(result parser.Result) parser.Result {
+	annotations := []parser.Annotation{}
+	for _, a := range result.Annotations {
+		if len(f.paths) > 0 && !pathMatches(f.paths, a.Path) {
+			continue
+		}
+		if pathMatches(f.excludePaths, a.Path) {
+			continue
+		}
+		if f.level != "" {
+			a.Level = f.level
+		}
+		annotations = append(annotations, a)
+	}
+	if dropped := len(result.Annotations) - len(annotations); dropped > 0 {
+		logrus.Debugf("Filtered out %d annotations by path", dropped)
+	}
+	result.Annotations = annotations
+	return result
+}
+
+type filteredParser struct {
+	filter checkFilter
+	parse  parser.Parser
+}
+
+func (f filteredParser) Run() (parser.Result, error) {
+	result, err := f.parse.Run()
+	if err != nil {
+		return result, err
+	}
+	return f.filter.apply(result), nil
+}
+
+// pathMatches reports whether a file path, or any directory containing it,
+// matches one of the glob patterns
+func pathMatches(patterns []string, filePath string) bool {
+	filePath = path.Clean(filepath.ToSlash(filePath))
+	for _, pattern := range patterns {
+		pattern = path.Clean(filepath.ToSlash(pattern))
+		for candidate := filePath; ; {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+			dir := path.Dir(candidate)
+			if dir == candidate || dir == "." || dir == "/" {
+				break
+			}
+			candidate = dir
+		}
+	}
+	return false
+}
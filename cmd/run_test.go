@@ -0,0 +1,146 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChecksConfig = `
+annotate-only: false
+checks:
+  golint:
+    parser: golint
+    level: error
+    annotate-only: true
+    exclude-paths:
+      - vendor
+  flake8:
+    name: Python lint
+    parser: regex
+    regex: '^(.*):(\d+):(\d+): (.*)$'
+    path-pos: 1
+    line-pos: 2
+    column-pos: 3
+    message-pos: 4
+    paths:
+      - src/*.py
+  broken:
+    parser: not-a-parser
+`
+
+func loadTestConfig(t *testing.T) *viper.Viper {
+	dir, err := ioutil.TempDir("", "checkbridge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".checkbridge.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testChecksConfig), 0644))
+
+	vip := viper.New()
+	vip.SetConfigFile(path)
+	require.NoError(t, vip.ReadInConfig())
+	return vip
+}
+
+func TestLoadCheck_Builtin(t *testing.T) {
+	check, err := loadCheck(loadTestConfig(t), nil, "golint")
+	require.NoError(t, err)
+	assert.Equal(t, "golint", check.name)
+	assert.True(t, check.config.GetBool("annotate-only"))
+
+	result, err := check.parse(bytes.NewBufferString(`
+main.go:1:1: exported function Main should have comment
+vendor/lib/lib.go:1:1: exported function Lib should have comment
+`)).Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Annotations))
+	assert.Equal(t, "main.go", result.Annotations[0].Path)
+	assert.Equal(t, parser.LevelError, result.Annotations[0].Level)
+}
+
+func TestLoadCheck_Regex(t *testing.T) {
+	check, err := loadCheck(loadTestConfig(t), nil, "flake8")
+	require.NoError(t, err)
+	assert.Equal(t, "Python lint", check.name)
+	assert.False(t, check.config.GetBool("annotate-only"))
+
+	result, err := check.parse(bytes.NewBufferString(`
+src/main.py:12:5: E225 missing whitespace around operator
+tests/test_main.py:3:1: F401 'os' imported but unused
+`)).Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result.Annotations))
+	a := result.Annotations[0]
+	assert.Equal(t, "src/main.py", a.Path)
+	assert.Equal(t, 12, a.Line)
+	assert.Equal(t, 5, a.Column)
+	assert.Equal(t, "E225 missing whitespace around operator", a.Message)
+}
+
+func TestLoadCheck_FlagsTakePrecedence(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Bool("annotate-only", false, "")
+	require.NoError(t, flags.Parse([]string{"--annotate-only=false"}))
+
+	vip := loadTestConfig(t)
+	vip.BindPFlags(flags)
+
+	check, err := loadCheck(vip, flags, "golint")
+	require.NoError(t, err)
+	assert.False(t, check.config.GetBool("annotate-only"))
+}
+
+func TestLoadCheck_Missing(t *testing.T) {
+	_, err := loadCheck(loadTestConfig(t), nil, "eslint")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no check named")
+}
+
+func TestLoadCheck_UnknownParser(t *testing.T) {
+	_, err := loadCheck(loadTestConfig(t), nil, "broken")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown parser")
+}
+
+func TestRunConfiguredCheck_Missing(t *testing.T) {
+	assert.Equal(t, 2, runConfiguredCheck(viper.New(), nil, "golint", nil))
+}
+
+func TestPathMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(pathMatches([]string{"vendor"}, "vendor/lib/lib.go"))
+	assert.True(pathMatches([]string{"src/*.py"}, "./src/main.py"))
+	assert.True(pathMatches([]string{"*.go"}, "main.go"))
+	assert.False(pathMatches([]string{"src/*.py"}, "tests/src/main.py"))
+	assert.False(pathMatches([]string{"vendor"}, "src/vendor.go"))
+	assert.False(pathMatches(nil, "main.go"))
+}
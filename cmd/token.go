@@ -0,0 +1,180 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/roverdotcom/checkbridge/github"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Print a GitHub app installation token, for use by other tools",
+	Long: `Print a GitHub app installation token, for use by other tools.
+
+Without --permission, the token has every permission of the installation.
+
+--export uses $GH_TOKEN, which the GitHub CLI reads, by default. Exporting
+$GITHUB_TOKEN would make later checkbridge runs use the token, which may not be
+allowed to create checks.`,
+	Example: `  eval "$(checkbridge token --permission pull_requests=write --export)"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		permissions, _ := cmd.Flags().GetStringArray("permission")
+		repositories, _ := cmd.Flags().GetStringSlice("repositories")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		export, _ := cmd.Flags().GetBool("export")
+		exportName, _ := cmd.Flags().GetString("export-name")
+		opts := tokenOptions{
+			permissions:  permissions,
+			repositories: repositories,
+			json:         asJSON,
+			export:       export,
+			exportName:   exportName,
+		}
+		if code := runToken(viper.GetViper(), opts, os.Stdout); code != 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	tokenCmd.Flags().StringArray("permission", nil, "permission to request, as name=level (e.g. 'pull_requests=write'), may be repeated")
+	tokenCmd.Flags().StringSlice("repositories", nil, "names of repositories to limit the token to (comma separated)")
+	tokenCmd.Flags().Bool("json", false, "print the token with its expiry and permissions as JSON")
+	tokenCmd.Flags().Bool("export", false, "print a shell command exporting the token")
+	tokenCmd.Flags().String("export-name", defaultExportName, "environment variable --export sets")
+}
+
+// defaultExportName is the variable --export sets by default. It's read by
+// the GitHub CLI, but not by checkbridge, unlike $GITHUB_TOKEN.
+const defaultExportName = "GH_TOKEN"
+
+// envNameRegex matches names which can be exported by a shell
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type tokenOptions struct {
+	permissions  []string
+	repositories []string
+	json         bool
+	export       bool
+	exportName   string
+}
+
+// tokenOutput is the token printed with --json. Expiry is unknown for
+// explicitly configured tokens, so it's left out.
+type tokenOutput struct {
+	Token        string            `json:"token"`
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"`
+	Permissions  map[string]string `json:"permissions,omitempty"`
+	Repositories []string          `json:"repositories,omitempty"`
+}
+
+func runToken(c config, opts tokenOptions, out io.Writer) int {
+	configureLogging(c)
+	if opts.json && opts.export {
+		logrus.Error("Only one of --json and --export can be used")
+		return 2
+	}
+	exportName := opts.exportName
+	if exportName == "" {
+		exportName = defaultExportName
+	}
+	if !envNameRegex.MatchString(exportName) {
+		logrus.Errorf("Invalid --export-name %q, expected an environment variable name", exportName)
+		return 2
+	}
+	if opts.export && (exportName == "GITHUB_TOKEN" || exportName == "CHECKBRIDGE_GITHUB_TOKEN") {
+		logrus.Warnf("checkbridge reads $%s, so later runs in this shell will use the exported token", exportName)
+	}
+
+	perms, err := parsePermissions(opts.permissions)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid --permission")
+		return 2
+	}
+
+	repo, err := newRepo(c)
+	if err != nil {
+		logrus.WithError(err).Error("Unable to determine repository")
+		return 3
+	}
+
+	if c.GetString("github-token") != "" && (len(perms) > 0 || len(opts.repositories) > 0) {
+		logrus.Warn("Using explicit GitHub token, --permission and --repositories have no effect")
+	}
+
+	auth := github.NewAuthProvider(c)
+	token, err := auth.CreateToken(repo, github.TokenRequest{
+		Permissions:  perms,
+		Repositories: opts.repositories,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Unable to get GitHub token")
+		return 4
+	}
+
+	switch {
+	case opts.json:
+		output := tokenOutput{
+			Token:        token.Token,
+			Permissions:  token.Permissions,
+			Repositories: token.Repositories,
+		}
+		if !token.ExpiresAt.IsZero() {
+			output.ExpiresAt = &token.ExpiresAt
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(output)
+	case opts.export:
+		_, err = fmt.Fprintf(out, "export %s=%s\n", exportName, token.Token)
+	default:
+		_, err = fmt.Fprintln(out, token.Token)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Unable to write token")
+		return 3
+	}
+	return 0
+}
+
+// parsePermissions parses name=level pairs into the permissions of a token
+// request
+func parsePermissions(pairs []string) (map[string]string, error) {
+	perms := map[string]string{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("expected name=level, got %q", pair)
+		}
+		perms[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return perms, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenConfig() *viper.Viper {
+	vip := viper.New()
+	vip.Set("github-token", "fake-token")
+	vip.Set("github-repo", "foo/bar")
+	return vip
+}
+
+func TestRunToken_Formats(t *testing.T) {
+	cases := []struct {
+		opts     tokenOptions
+		expected string
+	}{
+		{tokenOptions{}, "fake-token\n"},
+		{tokenOptions{export: true}, "export GH_TOKEN=fake-token\n"},
+		{tokenOptions{export: true, exportName: "REVIEW_TOKEN"}, "export REVIEW_TOKEN=fake-token\n"},
+	}
+	for _, tc := range cases {
+		out := bytes.Buffer{}
+		assert.Equal(t, 0, runToken(tokenConfig(), tc.opts, &out))
+		assert.Equal(t, tc.expected, out.String())
+	}
+}
+
+func TestRunToken_JSON(t *testing.T) {
+	out := bytes.Buffer{}
+	require.Equal(t, 0, runToken(tokenConfig(), tokenOptions{json: true}, &out))
+
+	printed := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &printed))
+	assert.Equal(t, map[string]interface{}{"token": "fake-token"}, printed)
+}
+
+func TestRunToken_InvalidOptions(t *testing.T) {
+	out := bytes.Buffer{}
+	assert.Equal(t, 2, runToken(tokenConfig(), tokenOptions{json: true, export: true}, &out))
+	assert.Equal(t, 2, runToken(tokenConfig(), tokenOptions{permissions: []string{"checks"}}, &out))
+	assert.Equal(t, 2, runToken(tokenConfig(), tokenOptions{export: true, exportName: "TOKEN; rm -rf ~"}, &out))
+	assert.Empty(t, out.String())
+}
+
+func TestRunToken_NoCredentials(t *testing.T) {
+	vip := viper.New()
+	vip.Set("github-repo", "foo/bar")
+	out := bytes.Buffer{}
+	assert.Equal(t, 4, runToken(vip, tokenOptions{}, &out))
+	assert.Empty(t, out.String())
+}
+
+func TestParsePermissions(t *testing.T) {
+	perms, err := parsePermissions([]string{"checks=write", " contents = read "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"checks": "write", "contents": "read"}, perms)
+
+	for _, invalid := range []string{"checks", "=write", "checks="} {
+		_, err := parsePermissions([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
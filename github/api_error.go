@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody limits how much of an error response is read
+const maxErrorBody = 64 * 1024
+
+// APIError is an error response from the GitHub API
+type APIError struct {
+	// StatusCode and Status are the HTTP status, e.g. 404 and "404 Not Found"
+	StatusCode int    `json:"-"`
+	Status     string `json:"-"`
+	// Message and DocumentationURL are set when GitHub sent a JSON error body
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+	// ContentType is the type of a body which wasn't JSON, e.g. an HTML error page
+	ContentType string `json:"-"`
+	// RequestID identifies the request, for GitHub support
+	RequestID string `json:"-"`
+	Method    string `json:"-"`
+	URL       string `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("GitHub API error %s", e.Status)
+	if e.Method != "" {
+		msg += fmt.Sprintf(" for %s %s", e.Method, e.URL)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	} else if e.ContentType != "" {
+		msg += fmt.Sprintf(" (%s response)", e.ContentType)
+	}
+
+	details := []string{}
+	if e.DocumentationURL != "" {
+		details = append(details, "see "+e.DocumentationURL)
+	}
+	if e.RequestID != "" {
+		details = append(details, "request ID "+e.RequestID)
+	}
+	if len(details) > 0 {
+		msg += fmt.Sprintf(" [%s]", strings.Join(details, ", "))
+	}
+	return msg
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RequestID:  resp.Header.Get("X-GitHub-Request-Id"),
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.URL = resp.Request.URL.String()
+	}
+
+	contentType := responseType(resp)
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+	// Don't trust the content type, proxies may mislabel GitHub's JSON
+	if json.Unmarshal(body, apiErr) != nil {
+		apiErr.ContentType = contentType
+	}
+	return apiErr
+}
+
+// responseType returns the media type of a response, without parameters
+func responseType(resp *http.Response) string {
+	header := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return header
+	}
+	return mediaType
+}
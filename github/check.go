@@ -20,7 +20,11 @@
 
 package github
 
-import "github.com/roverdotcom/checkbridge/parser"
+import (
+	"time"
+
+	"github.com/roverdotcom/checkbridge/parser"
+)
 
 // CheckStatus represents the status of a check (ongoing, completed)
 type CheckStatus string
@@ -44,10 +48,16 @@ const (
 
 // CheckRun represents the results (intermediate or complete) of a check run
 type CheckRun struct {
+	// ID is assigned by GitHub once the check run has been created
+	ID         int64           `json:"-"`
 	Name       string          `json:"name"`
 	HeadSHA    string          `json:"head_sha"`
 	Status     CheckStatus     `json:"status"`
 	Conclusion CheckConclusion `json:"conclusion,omitempty"`
 	DetailsURL string          `json:"details_url,omitempty"`
-	Output     parser.Result   `json:"output,omitempty"`
+	// ExternalID tags a created check run so retried creates can find it
+	ExternalID  string        `json:"external_id,omitempty"`
+	StartedAt   *time.Time    `json:"started_at,omitempty"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	Output      parser.Result `json:"output,omitempty"`
 }
@@ -20,14 +20,31 @@
 package github
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 
 	"github.com/sirupsen/logrus"
 )
 
+// maxAnnotations is the number of annotations GitHub accepts per request
+const maxAnnotations = 50
+
+// checkHeaders opt in to the Checks API preview, which GitHub Enterprise
+// Server releases still require even though github.com no longer does
+var checkHeaders = map[string]string{
+	"Accept": "application/vnd.github.antiope-preview+json",
+}
+
 // CheckClient is an interface to GitHub's API
 type CheckClient interface {
-	CreateCheck(CheckRun) error
+	CreateCheck(CheckRun) (int64, error)
+	UpdateCheck(CheckRun) error
 }
 
 type checkClient struct {
@@ -36,15 +53,22 @@ type checkClient struct {
 	repo  string
 }
 
-// NewCheckClient creates a GitHub API client for creating checks
-func NewCheckClient(token string, repo Repo) CheckClient {
+type checkRunResponse struct {
+	ID         int64  `json:"id"`
+	ExternalID string `json:"external_id"`
+}
+
+type checkRunsResponse struct {
+	CheckRuns []checkRunResponse `json:"check_runs"`
+}
+
+// NewCheckClient creates a GitHub API client for creating checks, using the
+// API URL from the given config
+func NewCheckClient(token string, repo Repo, c ConfigProvider) CheckClient {
 	return checkClient{
-		client: client{
-			apiBase:   apiBase,
-			authToken: token,
-		},
-		owner: repo.Owner(),
-		repo:  repo.Name(),
+		client: newClient(c, token),
+		owner:  repo.Owner(),
+		repo:   repo.Name(),
 	}
 }
 
@@ -52,24 +76,161 @@ func (c checkClient) checkURL() string {
 	return fmt.Sprintf("repos/%s/%s/check-runs", c.owner, c.repo)
 }
 
-func (c checkClient) CreateCheck(check CheckRun) error {
-	if len(check.Output.Annotations) > 50 {
-		logrus.Warnf("More than 50 annotations provided (%d), only sending first 50", len(check.Output.Annotations))
-		check.Output.Annotations = check.Output.Annotations[:50]
+func (c checkClient) checkRunURL(id int64) string {
+	return fmt.Sprintf("%s/%d", c.checkURL(), id)
+}
+
+// batchAnnotations splits a check run into runs of at most maxAnnotations
+// annotations each. Only the last batch keeps the requested status,
+// conclusion and completion time, so the check isn't completed before every
+// annotation is sent.
+func batchAnnotations(check CheckRun) []CheckRun {
+	annotations := check.Output.Annotations
+	if len(annotations) <= maxAnnotations {
+		return []CheckRun{check}
 	}
 
-	headers := map[string]string{
-		"Accept": "application/vnd.github.antiope-preview+json",
+	batches := []CheckRun{}
+	for start := 0; start < len(annotations); start += maxAnnotations {
+		end := start + maxAnnotations
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+		batch := check
+		batch.Output.Annotations = annotations[start:end]
+		if end < len(annotations) {
+			batch.Status = CheckStatusInProgress
+			batch.Conclusion = ""
+			// GitHub rejects a completion time without a conclusion
+			batch.CompletedAt = nil
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func (c checkClient) CreateCheck(check CheckRun) (int64, error) {
+	batches := batchAnnotations(check)
+	if len(batches) > 1 {
+		logrus.Debugf("Sending %d annotations in %d batches", len(check.Output.Annotations), len(batches))
 	}
-	postResponse := map[string]interface{}{}
-	resp, err := c.postJSON(c.checkURL(), check, headers, &postResponse)
+
+	id, err := c.create(batches[0])
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	for _, batch := range batches[1:] {
+		batch.ID = id
+		if err := c.update(batch); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
+}
+
+// create POSTs a new check run. Since a request which failed from our side
+// may still have created the run, retries first look for a run tagged with
+// the same external ID, so retrying never creates duplicate runs.
+func (c checkClient) create(check CheckRun) (int64, error) {
+	if check.ExternalID == "" {
+		externalID, err := newExternalID()
+		if err != nil {
+			return 0, err
+		}
+		check.ExternalID = externalID
+	}
+
+	body := bytes.Buffer{}
+	if err := json.NewEncoder(&body).Encode(check); err != nil {
+		return 0, err
 	}
 
-	logrus.WithField("status", resp.Status).WithField("body", postResponse).Debug("Got check create response")
+	var existing int64
+	resp, err := c.withRetries(func(attempt int) (*http.Response, error) {
+		if attempt > 0 {
+			id, err := c.findCheck(check)
+			if err != nil {
+				logrus.WithError(err).Warn("Could not look for a check run created by an earlier attempt")
+			} else if id != 0 {
+				existing = id
+				return nil, nil
+			}
+		}
+		return c.send(http.MethodPost, c.checkURL(), body.Bytes(), checkHeaders)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if existing != 0 {
+		logrus.WithField("id", existing).Info("Check run was created by an earlier attempt")
+		return existing, nil
+	}
+
+	created := checkRunResponse{}
+	if _, err := c.decodeResponse(resp, &created); err != nil {
+		return 0, err
+	}
+	logrus.WithField("status", resp.Status).WithField("id", created.ID).Debug("Got check create response")
 	if resp.StatusCode != 201 {
-		return fmt.Errorf("error response from GitHub %d: %s", resp.StatusCode, postResponse)
+		return 0, fmt.Errorf("unexpected response from GitHub: %s", resp.Status)
+	}
+	return created.ID, nil
+}
+
+// findCheck returns the ID of the check run with the same name and
+// external ID on the head commit, or zero if there is none
+func (c checkClient) findCheck(check CheckRun) (int64, error) {
+	query := url.Values{}
+	query.Set("check_name", check.Name)
+	query.Set("filter", "all")
+	runsURL := fmt.Sprintf("repos/%s/%s/commits/%s/check-runs?%s", c.owner, c.repo, check.HeadSHA, query.Encode())
+
+	runs := checkRunsResponse{}
+	resp, err := c.getJSON(runsURL, checkHeaders, &runs)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("unexpected response from GitHub: %s", resp.Status)
+	}
+	for _, run := range runs.CheckRuns {
+		if run.ExternalID == check.ExternalID {
+			return run.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+func newExternalID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("error generating check run external ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func (c checkClient) UpdateCheck(check CheckRun) error {
+	if check.ID == 0 {
+		return errors.New("cannot update check run without an ID")
+	}
+	for _, batch := range batchAnnotations(check) {
+		if err := c.update(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c checkClient) update(check CheckRun) error {
+	updated := checkRunResponse{}
+	resp, err := c.patchJSON(c.checkRunURL(check.ID), check, checkHeaders, &updated)
+	if err != nil {
+		return err
+	}
+	logrus.WithField("status", resp.Status).WithField("id", check.ID).Debug("Got check update response")
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("unexpected response from GitHub: %s", resp.Status)
 	}
 	return nil
 }
@@ -25,8 +25,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -61,7 +63,8 @@ func createCheckWithRun(handler http.Handler, run CheckRun) error {
 		owner: "owner",
 	}
 
-	return client.CreateCheck(run)
+	_, err := client.CreateCheck(run)
+	return err
 }
 
 func createCheck(handler http.Handler) error {
@@ -72,8 +75,39 @@ func createCheck(handler http.Handler) error {
 
 func TestNewCheck_client(t *testing.T) {
 	r := dummyRepo{"owner", "repo"}
-	c := NewCheckClient("token", r)
+	c := NewCheckClient("token", r, viper.New())
 	assert.Equal(t, c.(checkClient).owner, "owner")
+	assert.Equal(t, apiBase, c.(checkClient).apiBase)
+}
+
+func TestNewCheckClient_Enterprise(t *testing.T) {
+	var paths []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		assert.Equal(t, checkHeaders["Accept"], r.Header.Get("Accept"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if r.Method == http.MethodPost {
+			w.WriteHeader(201)
+		}
+		w.Write([]byte(`{"id": 7}`))
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/api/v3/", handler)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	vip := viper.New()
+	vip.Set("github-api-url", server.URL+"/")
+	c := NewCheckClient("token", dummyRepo{"owner", "repo"}, vip)
+
+	id, err := c.CreateCheck(CheckRun{Status: CheckStatusInProgress})
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateCheck(CheckRun{ID: id, Status: CheckStatusCompleted}))
+
+	assert.Equal(t, []string{
+		"POST /api/v3/repos/owner/repo/check-runs",
+		"PATCH /api/v3/repos/owner/repo/check-runs/7",
+	}, paths)
 }
 
 func TestCreateCheck_OK(t *testing.T) {
@@ -83,31 +117,137 @@ func TestCreateCheck_OK(t *testing.T) {
 	assert.True(t, handler.called)
 }
 
+func TestCreateCheck_ReturnsID(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		w.Write([]byte(`{"id": 1234}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	c := checkClient{
+		client: client{
+			apiBase:   server.URL,
+			authToken: "fake-token",
+		},
+		repo:  "repo",
+		owner: "owner",
+	}
+	id, err := c.CreateCheck(CheckRun{Status: CheckStatusInProgress})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234), id)
+}
+
 func TestCreateCheck_NotFound(t *testing.T) {
 	handler := createHandler(404)
 	err := createCheck(&handler)
 	assert.Error(t, err)
 }
 func TestCreateCheck_ManyAnnotations(t *testing.T) {
-	sentRun := CheckRun{}
-	annotations := make([]parser.Annotation, 100)
+	annotations := make([]parser.Annotation, 120)
+	for i := range annotations {
+		annotations[i].Line = i
+	}
+	completed := time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)
 	run := CheckRun{
+		Status:      CheckStatusCompleted,
+		Conclusion:  CheckConclusionFailure,
+		CompletedAt: &completed,
 		Output: parser.Result{
 			Annotations: annotations,
 		},
 	}
+
+	sentRuns := []CheckRun{}
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(201)
-		w.Write([]byte(`{}`))
+		sentRun := CheckRun{}
 		decoder := json.NewDecoder(r.Body)
 		defer r.Body.Close()
 		require.NoError(t, decoder.Decode(&sentRun))
+		sentRuns = append(sentRuns, sentRun)
+
+		if r.Method == http.MethodPost {
+			assert.Equal(t, "/repos/owner/repo/check-runs", r.URL.Path)
+			w.WriteHeader(201)
+			w.Write([]byte(`{"id": 42}`))
+			return
+		}
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/repos/owner/repo/check-runs/42", r.URL.Path)
+		w.Write([]byte(`{"id": 42}`))
 	})
 
 	err := createCheckWithRun(handler, run)
-
 	require.NoError(t, err, "error sending check")
-	assert.Equal(t, 50, len(sentRun.Output.Annotations), "expected large annotation list truncated to 50")
+	require.Equal(t, 3, len(sentRuns), "expected annotations sent in batches of 50")
+
+	received := []parser.Annotation{}
+	for i, sent := range sentRuns {
+		assert.True(t, len(sent.Output.Annotations) <= 50, "batch larger than 50 annotations")
+		received = append(received, sent.Output.Annotations...)
+		if i < len(sentRuns)-1 {
+			assert.Equal(t, CheckStatusInProgress, sent.Status)
+			assert.Empty(t, sent.Conclusion)
+			assert.Nil(t, sent.CompletedAt, "only the last batch may complete the check")
+		}
+	}
+	assert.Equal(t, annotations, received, "expected every annotation delivered in order")
+
+	last := sentRuns[len(sentRuns)-1]
+	assert.Equal(t, CheckStatusCompleted, last.Status)
+	assert.Equal(t, CheckConclusionFailure, last.Conclusion)
+	require.NotNil(t, last.CompletedAt)
+	assert.True(t, completed.Equal(*last.CompletedAt))
+}
+
+func TestCreateCheck_BatchUpdateFails(t *testing.T) {
+	run := CheckRun{
+		Output: parser.Result{
+			Annotations: make([]parser.Annotation, 60),
+		},
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(201)
+			w.Write([]byte(`{"id": 42}`))
+			return
+		}
+		w.WriteHeader(422)
+		w.Write([]byte(`{"message": "Validation Failed"}`))
+	})
+
+	err := createCheckWithRun(handler, run)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Validation Failed")
+}
+
+func TestUpdateCheck_OK(t *testing.T) {
+	patched := false
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/repos/owner/repo/check-runs/1234", r.URL.Path)
+		assert.NotEmpty(t, r.Header.Get("Accept"), "missing Accept header")
+		patched = true
+		w.Write([]byte(`{"id": 1234}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	c := checkClient{
+		client: client{
+			apiBase:   server.URL,
+			authToken: "fake-token",
+		},
+		repo:  "repo",
+		owner: "owner",
+	}
+	require.NoError(t, c.UpdateCheck(CheckRun{ID: 1234}))
+	assert.True(t, patched)
+}
+
+func TestUpdateCheck_NoID(t *testing.T) {
+	c := checkClient{}
+	assert.Error(t, c.UpdateCheck(CheckRun{}))
 }
 
 func TestCreateCheck_BadURL(t *testing.T) {
@@ -116,5 +256,81 @@ func TestCreateCheck_BadURL(t *testing.T) {
 			apiBase: "gopher://",
 		},
 	}
-	assert.Error(t, c.CreateCheck(CheckRun{}))
+	_, err := c.CreateCheck(CheckRun{})
+	assert.Error(t, err)
+}
+
+func TestCreateCheck_RetryFindsEarlierRun(t *testing.T) {
+	defer stubSleep()()
+
+	posts := 0
+	var externalID string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			run := CheckRun{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&run))
+			externalID = run.ExternalID
+			// The run is created, but the response never makes it back
+			w.WriteHeader(502)
+			return
+		}
+		assert.Equal(t, "/repos/owner/repo/commits/abc/check-runs", r.URL.Path)
+		assert.Equal(t, "my-name", r.URL.Query().Get("check_name"))
+		w.Write([]byte(`{"check_runs": [
+			{"id": 1, "external_id": "someone-else"},
+			{"id": 2, "external_id": "` + externalID + `"}
+		]}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	c := checkClient{
+		client: client{
+			apiBase: server.URL,
+			retries: 3,
+		},
+		repo:  "repo",
+		owner: "owner",
+	}
+	id, err := c.CreateCheck(CheckRun{Name: "my-name", HeadSHA: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), id)
+	assert.Equal(t, 1, posts)
+	assert.NotEmpty(t, externalID)
+}
+
+func TestCreateCheck_RetryRecreates(t *testing.T) {
+	defer stubSleep()()
+
+	var externalIDs []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"check_runs": []}`))
+			return
+		}
+		run := CheckRun{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&run))
+		externalIDs = append(externalIDs, run.ExternalID)
+		if len(externalIDs) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(201)
+		w.Write([]byte(`{"id": 5}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	c := checkClient{
+		client: client{
+			apiBase: server.URL,
+			retries: 3,
+		},
+	}
+	id, err := c.CreateCheck(CheckRun{Name: "my-name"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), id)
+	require.Len(t, externalIDs, 2)
+	assert.Equal(t, externalIDs[0], externalIDs[1])
 }
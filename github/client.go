@@ -23,73 +23,284 @@ package github
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 const apiBase = "https://api.github.com"
 
+// enterpriseAPIPath is where GitHub Enterprise Server serves its REST API
+const enterpriseAPIPath = "/api/v3"
+
+// apiBaseURL returns the REST API root for the configured github-api-url,
+// defaulting to github.com. A bare GitHub Enterprise Server host like
+// https://github.example.com is expanded to its /api/v3 root.
+func apiBaseURL(c ConfigProvider) string {
+	base := strings.TrimRight(c.GetString("github-api-url"), "/")
+	if base == "" {
+		return apiBase
+	}
+
+	parsed, err := url.Parse(base)
+	if err != nil || parsed.Host == "" {
+		logrus.WithField("url", base).Warn("Invalid GitHub API URL, using it as is")
+		return base
+	}
+	if parsed.Path == "" && parsed.Host != "api.github.com" {
+		return base + enterpriseAPIPath
+	}
+	return base
+}
+
+const (
+	defaultTimeout = 30 * time.Second
+	defaultRetries = 3
+
+	// retryBaseDelay is the backoff before the first retry, doubled for each
+	// following one
+	retryBaseDelay = time.Second
+	// maxRetryDelay is the longest we'll wait before retrying; if GitHub asks
+	// for a longer wait (e.g. an exhausted rate limit) we give up instead
+	maxRetryDelay = time.Minute
+)
+
+// sleep and now are variables so tests can avoid actually waiting
+var sleep = time.Sleep
+var now = time.Now
+
+// jitterSource is seeded so parallel CI jobs don't all pick the same delays,
+// as they would with math/rand's default source before Go 1.20
+var jitterSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+
 type client struct {
 	authToken string
 	apiBase   string
+	timeout   time.Duration
+	retries   int
+}
+
+// newClient creates a client configured from the github-api-url,
+// github-timeout and github-retries settings
+func newClient(c ConfigProvider, token string) client {
+	return client{
+		authToken: token,
+		apiBase:   apiBaseURL(c),
+		timeout:   durationSetting(c, "github-timeout", defaultTimeout),
+		retries:   intSetting(c, "github-retries", defaultRetries),
+	}
+}
+
+func durationSetting(c ConfigProvider, key string, fallback time.Duration) time.Duration {
+	value := c.GetString(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithField(key, value).Warnf("Invalid duration, using %s", fallback)
+		return fallback
+	}
+	return duration
+}
+
+func intSetting(c ConfigProvider, key string, fallback int) int {
+	value := c.GetString(key)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		logrus.WithField(key, value).Warnf("Invalid number, using %d", fallback)
+		return fallback
+	}
+	return number
 }
 
 func (c client) addAuthHeader(req *http.Request) {
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.authToken))
 }
 
-func (c client) decodeResponse(req *http.Request, result interface{}) (*http.Response, error) {
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+func (c client) httpClient() *http.Client {
+	timeout := c.timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
 	}
+	return &http.Client{Timeout: timeout}
+}
 
+// decodeResponse decodes a successful JSON response into result, returning
+// an *APIError for error responses
+func (c client) decodeResponse(resp *http.Response, result interface{}) (*http.Response, error) {
 	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError(resp)
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return resp, nil
+	}
+
 	decoder := json.NewDecoder(resp.Body)
 	if err := decoder.Decode(result); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error decoding %s response from GitHub (%s): %w", resp.Status, responseType(resp), err)
 	}
 
 	return resp, nil
 }
 
-func (c client) getJSON(url string, headers map[string]string, result interface{}) (*http.Response, error) {
+// send makes a single request, without retries
+func (c client) send(method string, url string, body []byte, headers map[string]string) (*http.Response, error) {
 	fullURL := fmt.Sprintf("%s/%s", c.apiBase, url)
-	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
+	logrus.WithField("url", fullURL).WithField("method", method).WithField("body", string(body)).Debug("Making HTTP request to GitHub API")
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, fullURL, reader)
 	if err != nil {
 		return nil, err
 	}
 	c.addAuthHeader(req)
-
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
+	return c.httpClient().Do(req)
+}
+
+// withRetries calls attempt until it returns a response which isn't worth
+// retrying or retries run out. attempt is passed the number of the attempt,
+// starting at zero.
+func (c client) withRetries(attempt func(int) (*http.Response, error)) (*http.Response, error) {
+	for n := 0; ; n++ {
+		resp, err := attempt(n)
+		if !shouldRetry(resp, err) || n >= c.retries {
+			return resp, err
+		}
+
+		delay, ok := retryDelay(resp, n)
+		if !ok {
+			return resp, err
+		}
+
+		entry := logrus.WithField("delay", delay).WithField("attempt", n+1)
+		if err != nil {
+			entry.WithError(err).Warn("GitHub request failed, retrying")
+		} else {
+			entry.WithField("status", resp.Status).Warn("GitHub request failed, retrying")
+			resp.Body.Close()
+		}
+		sleep(delay)
+	}
+}
 
-	return c.decodeResponse(req, result)
+// shouldRetry reports whether a request failed in a way that might succeed
+// later: network errors, server errors and rate limiting
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		// Only network failures are worth retrying, not e.g. a bad URL
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) {
+			return false
+		}
+		var netErr net.Error
+		return errors.As(urlErr.Err, &netErr) || errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF)
+	}
+	if resp == nil {
+		return false
+	}
+	switch {
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusForbidden:
+		// Secondary rate limits set Retry-After, primary ones exhaust the limit
+		return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return false
 }
 
-func (c client) postJSON(url string, body interface{}, headers map[string]string, result interface{}) (*http.Response, error) {
-	buf := bytes.Buffer{}
-	if err := json.NewEncoder(&buf).Encode(body); err != nil {
-		return nil, err
+// retryDelay returns how long to wait before retry number n+1. Waits
+// requested by GitHub are honored, otherwise the delay grows exponentially
+// with jitter. It returns false when GitHub asks for a wait too long to be
+// worth it.
+func retryDelay(resp *http.Response, n int) (time.Duration, bool) {
+	if resp != nil {
+		if delay, ok := requestedDelay(resp.Header); ok {
+			return delay, delay <= maxRetryDelay
+		}
 	}
-	fullURL := fmt.Sprintf("%s/%s", c.apiBase, url)
-	logrus.WithField("url", fullURL).WithField("body", buf.String()).Debug("Making HTTP request to GitHub API")
 
-	req, err := http.NewRequest(http.MethodPost, fullURL, &buf)
+	backoff := retryBaseDelay << uint(n)
+	if backoff > maxRetryDelay {
+		backoff = maxRetryDelay
+	}
+	// Wait between half and all of the backoff, so parallel CI jobs spread out
+	jitter := time.Duration(jitterSource.Int63n(int64(backoff)/2 + 1))
+	return backoff/2 + jitter, true
+}
+
+func requestedDelay(header http.Header) (time.Duration, bool) {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return nonNegative(at.Sub(now())), true
+		}
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return nonNegative(time.Unix(reset, 0).Sub(now())), true
+		}
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func (c client) getJSON(url string, headers map[string]string, result interface{}) (*http.Response, error) {
+	resp, err := c.withRetries(func(int) (*http.Response, error) {
+		return c.send(http.MethodGet, url, nil, headers)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return c.decodeResponse(resp, result)
+}
 
-	c.addAuthHeader(req)
+func (c client) postJSON(url string, body interface{}, headers map[string]string, result interface{}) (*http.Response, error) {
+	return c.sendJSON(http.MethodPost, url, body, headers, result)
+}
 
-	for k, v := range headers {
-		req.Header.Add(k, v)
-	}
+func (c client) patchJSON(url string, body interface{}, headers map[string]string, result interface{}) (*http.Response, error) {
+	return c.sendJSON(http.MethodPatch, url, body, headers, result)
+}
 
-	return c.decodeResponse(req, result)
+func (c client) sendJSON(method string, url string, body interface{}, headers map[string]string, result interface{}) (*http.Response, error) {
+	buf := bytes.Buffer{}
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	resp, err := c.withRetries(func(int) (*http.Response, error) {
+		return c.send(method, url, buf.Bytes(), headers)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeResponse(resp, result)
 }
@@ -20,10 +20,13 @@
 package github
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -81,3 +84,255 @@ func TestPostJSON_OK(t *testing.T) {
 		assert.Equal(t, "world", data["hello"])
 	})
 }
+
+func TestAPIBaseURL(t *testing.T) {
+	cases := map[string]string{
+		"":                                    "https://api.github.com",
+		"https://api.github.com/":             "https://api.github.com",
+		"https://github.example.com":          "https://github.example.com/api/v3",
+		"https://github.example.com/":         "https://github.example.com/api/v3",
+		"https://github.example.com/api/v3/":  "https://github.example.com/api/v3",
+		"http://localhost:8080/custom/prefix": "http://localhost:8080/custom/prefix",
+	}
+	for input, expected := range cases {
+		vip := viper.New()
+		vip.Set("github-api-url", input)
+		assert.Equal(t, expected, apiBaseURL(vip), input)
+	}
+}
+
+// stubSleep records retry delays instead of waiting, returning a function
+// restoring the real sleep
+func stubSleep() func() {
+	return stubSleepInto(&[]time.Duration{})
+}
+
+func stubSleepInto(delays *[]time.Duration) func() {
+	sleep = func(d time.Duration) {
+		*delays = append(*delays, d)
+	}
+	return func() {
+		sleep = time.Sleep
+	}
+}
+
+func withStatuses(headers http.Header, statuses ...int) (*httptest.Server, *int) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[calls]
+		calls++
+		if status != 200 {
+			for k, v := range headers {
+				w.Header()[k] = v
+			}
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"answer": 42}`))
+	})
+	return httptest.NewServer(handler), &calls
+}
+
+func TestGetJSON_RetriesServerErrors(t *testing.T) {
+	delays := []time.Duration{}
+	defer stubSleepInto(&delays)()
+
+	server, calls := withStatuses(nil, 500, 502, 200)
+	defer server.Close()
+
+	c := client{apiBase: server.URL, retries: 3}
+	data := map[string]int{}
+	resp, err := c.getJSON("foo", nil, &data)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 42, data["answer"])
+	assert.Equal(t, 3, *calls)
+
+	require.Len(t, delays, 2)
+	assert.True(t, delays[0] >= retryBaseDelay/2 && delays[0] <= retryBaseDelay, delays[0])
+	assert.True(t, delays[1] >= retryBaseDelay && delays[1] <= 2*retryBaseDelay, delays[1])
+}
+
+func TestGetJSON_GivesUpAfterRetries(t *testing.T) {
+	defer stubSleep()()
+
+	server, calls := withStatuses(nil, 500, 500, 500)
+	defer server.Close()
+
+	c := client{apiBase: server.URL, retries: 2}
+	_, err := c.getJSON("foo", nil, &map[string]int{})
+	assertStatus(t, 500, err)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestGetJSON_NoRetryOnClientError(t *testing.T) {
+	defer stubSleep()()
+
+	server, calls := withStatuses(nil, 404)
+	defer server.Close()
+
+	c := client{apiBase: server.URL, retries: 3}
+	_, err := c.getJSON("foo", nil, &map[string]int{})
+	assertStatus(t, 404, err)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestPostJSON_RetryAfter(t *testing.T) {
+	delays := []time.Duration{}
+	defer stubSleepInto(&delays)()
+
+	headers := http.Header{"Retry-After": []string{"7"}}
+	server, calls := withStatuses(headers, 403, 200)
+	defer server.Close()
+
+	c := client{apiBase: server.URL, retries: 3}
+	_, err := c.postJSON("foo", nil, nil, &map[string]int{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, []time.Duration{7 * time.Second}, delays)
+}
+
+func TestGetJSON_RateLimitReset(t *testing.T) {
+	delays := []time.Duration{}
+	defer stubSleepInto(&delays)()
+	fixed := time.Unix(1000000, 0)
+	now = func() time.Time { return fixed }
+	defer func() { now = time.Now }()
+
+	headers := http.Header{
+		"X-Ratelimit-Remaining": []string{"0"},
+		"X-Ratelimit-Reset":     []string{"1000030"},
+	}
+	server, calls := withStatuses(headers, 403, 200)
+	defer server.Close()
+
+	c := client{apiBase: server.URL, retries: 3}
+	_, err := c.getJSON("foo", nil, &map[string]int{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, []time.Duration{30 * time.Second}, delays)
+}
+
+func TestGetJSON_RateLimitTooLong(t *testing.T) {
+	defer stubSleep()()
+
+	headers := http.Header{"Retry-After": []string{"3600"}}
+	server, calls := withStatuses(headers, 429, 200)
+	defer server.Close()
+
+	c := client{apiBase: server.URL, retries: 3}
+	_, err := c.getJSON("foo", nil, &map[string]int{})
+	assertStatus(t, 429, err)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestGetJSON_RetriesNetworkErrors(t *testing.T) {
+	delays := []time.Duration{}
+	defer stubSleepInto(&delays)()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c := client{apiBase: server.URL, retries: 2}
+	_, err := c.getJSON("foo", nil, nil)
+	assert.Error(t, err)
+	assert.Len(t, delays, 2)
+}
+
+func TestNewClient_Settings(t *testing.T) {
+	vip := viper.New()
+	c := newClient(vip, "token")
+	assert.Equal(t, defaultTimeout, c.timeout)
+	assert.Equal(t, defaultRetries, c.retries)
+
+	vip.Set("github-timeout", "5s")
+	vip.Set("github-retries", "0")
+	c = newClient(vip, "token")
+	assert.Equal(t, 5*time.Second, c.timeout)
+	assert.Equal(t, 0, c.retries)
+}
+
+func assertStatus(t *testing.T, status int, err error) {
+	apiErr := &APIError{}
+	if assert.True(t, errors.As(err, &apiErr), "expected an API error, got %v", err) {
+		assert.Equal(t, status, apiErr.StatusCode)
+	}
+}
+
+func TestGetJSON_NoContent(t *testing.T) {
+	handle := func(w http.ResponseWriter) {
+		w.WriteHeader(204)
+	}
+	withResponse(t, handle, func(c client) {
+		resp, err := c.getJSON("foo", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 204, resp.StatusCode)
+	})
+}
+
+func TestGetJSON_HTMLError(t *testing.T) {
+	handle := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+		w.WriteHeader(502)
+		w.Write([]byte("<html><body>Bad gateway</body></html>"))
+	}
+	withResponse(t, handle, func(c client) {
+		_, err := c.getJSON("foo", nil, &map[string]int{})
+		assertStatus(t, 502, err)
+		assert.Contains(t, err.Error(), "502 Bad Gateway")
+		assert.Contains(t, err.Error(), "text/html")
+		assert.Contains(t, err.Error(), "ABCD:1234")
+		assert.NotContains(t, err.Error(), "invalid character")
+	})
+}
+
+func TestGetJSON_HTMLSuccess(t *testing.T) {
+	handle := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	}
+	withResponse(t, handle, func(c client) {
+		_, err := c.getJSON("foo", nil, &map[string]int{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "200 OK")
+		assert.Contains(t, err.Error(), "text/html")
+	})
+}
+
+func TestPostJSON_JSONError(t *testing.T) {
+	handle := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(422)
+		w.Write([]byte(`{"message": "Validation Failed", "documentation_url": "https://docs.github.com/rest"}`))
+	}
+	withResponse(t, handle, func(c client) {
+		_, err := c.postJSON("foo", nil, nil, &map[string]int{})
+		assertStatus(t, 422, err)
+
+		apiErr := &APIError{}
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "Validation Failed", apiErr.Message)
+		assert.Equal(t, "https://docs.github.com/rest", apiErr.DocumentationURL)
+		assert.Equal(t, http.MethodPost, apiErr.Method)
+		assert.Contains(t, apiErr.URL, "/foo")
+		assert.Contains(t, err.Error(), "Validation Failed")
+	})
+}
+
+func TestGetJSON_ErrorWithStatusInBody(t *testing.T) {
+	handle := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(404)
+		w.Write([]byte(`{"message": "Not Found", "documentation_url": "https://docs.github.com/rest", "status": "404"}`))
+	}
+	withResponse(t, handle, func(c client) {
+		_, err := c.getJSON("foo", nil, &map[string]int{})
+		assertStatus(t, 404, err)
+
+		apiErr := &APIError{}
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "404 Not Found", apiErr.Status)
+		assert.Equal(t, "Not Found", apiErr.Message)
+		assert.Empty(t, apiErr.ContentType)
+	})
+}
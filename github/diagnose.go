@@ -0,0 +1,189 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package github
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AuthStep is the outcome of one step of checking GitHub app authentication
+type AuthStep struct {
+	Name string `json:"name"`
+	// Status is one of "ok", "failed" or "skipped"
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	err error
+}
+
+const (
+	stepOK      = "ok"
+	stepFailed  = "failed"
+	stepSkipped = "skipped"
+)
+
+// AuthDiagnosis reports each step of authenticating as a GitHub app, so
+// misconfiguration can be pinned down
+type AuthDiagnosis struct {
+	Steps []AuthStep `json:"steps"`
+}
+
+// Err returns the error of the first failed step, or nil if all succeeded
+func (d AuthDiagnosis) Err() error {
+	for _, step := range d.Steps {
+		if step.Status == stepFailed {
+			return fmt.Errorf("%s: %w", step.Name, step.err)
+		}
+	}
+	return nil
+}
+
+func (d *AuthDiagnosis) run(name string, step func() (string, error)) bool {
+	if d.Err() != nil {
+		d.Steps = append(d.Steps, AuthStep{Name: name, Status: stepSkipped})
+		return false
+	}
+	detail, err := step()
+	if err != nil {
+		d.Steps = append(d.Steps, AuthStep{Name: name, Status: stepFailed, Error: err.Error(), err: err})
+		return false
+	}
+	d.Steps = append(d.Steps, AuthStep{Name: name, Status: stepOK, Detail: detail})
+	return true
+}
+
+type appResponse struct {
+	ID   int    `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// DiagnoseAuth authenticates as the configured GitHub app step by step,
+// checking the installation on r grants perms. With an explicit GitHub
+// token there are no steps to check.
+func DiagnoseAuth(c ConfigProvider, r Repo, perms map[string]string) AuthDiagnosis {
+	d := AuthDiagnosis{}
+	if c.GetString("github-token") != "" {
+		d.Steps = append(d.Steps, AuthStep{
+			Name:   "token",
+			Status: stepOK,
+			Detail: "using explicit GitHub token, app authentication not checked",
+		})
+		return d
+	}
+
+	g := githubAuth{config: c, apiBase: apiBaseURL(c)}
+	d.run("private key", func() (string, error) {
+		privateKey := c.GetString("private-key")
+		if privateKey == "" {
+			return "", errors.New("no private key provided")
+		}
+		key, err := g.readPrivateKey(privateKey)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("RSA key, %d bits", key.N.BitLen()), nil
+	})
+
+	appClient := tokenClient{}
+	d.run("app", func() (string, error) {
+		appJWT, err := g.makeJWT()
+		if err != nil {
+			return "", err
+		}
+		appClient.client = newClient(c, appJWT)
+		app := appResponse{}
+		if _, err := appClient.getJSON("app", tokenAuthHeaders, &app); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("authenticated as %s (%s, ID %d)", app.Name, app.Slug, app.ID), nil
+	})
+
+	installation := installationResponse{}
+	d.run("installation", func() (string, error) {
+		url := fmt.Sprintf("repos/%s/%s/installation", r.Owner(), r.Name())
+		if id := c.GetString("installation-id"); id != "0" && id != "" {
+			url = "app/installations/" + id
+		}
+		if _, err := appClient.getJSON(url, tokenAuthHeaders, &installation); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("installation %d on %s %s, with access to %s repositories",
+			installation.ID, strings.ToLower(installation.Account.Type), installation.Account.Login, installation.RepositorySelection), nil
+	})
+
+	d.run("permissions", func() (string, error) {
+		if missing := missingPermissions(installation.Permissions, perms); len(missing) > 0 {
+			return "", fmt.Errorf("installation is missing %s", strings.Join(missing, ", "))
+		}
+		return "granted " + formatPermissions(installation.Permissions), nil
+	})
+
+	repoClient := client{}
+	d.run("token", func() (string, error) {
+		token, err := appClient.getAccesssToken(fmt.Sprint(installation.ID), TokenRequest{Permissions: perms})
+		if err != nil {
+			return "", err
+		}
+		repoClient = newClient(c, token.Token)
+		return fmt.Sprintf("expires at %s", token.ExpiresAt.Local().Format("15:04:05 MST")), nil
+	})
+
+	d.run("repository", func() (string, error) {
+		url := fmt.Sprintf("repos/%s/%s", r.Owner(), r.Name())
+		if _, err := repoClient.getJSON(url, nil, &struct{}{}); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("can access %s/%s", r.Owner(), r.Name()), nil
+	})
+
+	return d
+}
+
+var permissionLevels = map[string]int{"read": 1, "write": 2, "admin": 3}
+
+// missingPermissions lists the required permissions not granted at the
+// required level or higher
+func missingPermissions(granted, required map[string]string) []string {
+	missing := []string{}
+	for name, level := range required {
+		has, ok := granted[name]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s:%s", name, level))
+		} else if permissionLevels[has] < permissionLevels[level] {
+			missing = append(missing, fmt.Sprintf("%s:%s (has %s)", name, level, has))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func formatPermissions(perms map[string]string) string {
+	formatted := make([]string, 0, len(perms))
+	for name, level := range perms {
+		formatted = append(formatted, name+":"+level)
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ", ")
+}
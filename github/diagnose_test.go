@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package github
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diagnoseWith(t *testing.T, permissions string, installationStatus int) AuthDiagnosis {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1, "slug": "my-checks", "name": "My checks"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo/installation", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(installationStatus)
+		w.Write([]byte(`{
+			"id": 42,
+			"account": {"login": "org", "type": "Organization"},
+			"repository_selection": "selected",
+			"permissions": ` + permissions + `
+		}`))
+	})
+	mux.HandleFunc("/api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(201)
+		w.Write([]byte(`{"token": "installation.token", "expires_at": "2020-06-01T12:00:00Z"}`))
+	})
+	mux.HandleFunc("/api/v3/repos/org/repo", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer installation.token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"full_name": "org/repo"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	vip := viper.New()
+	vip.Set("private-key", testPrivateKey)
+	vip.Set("application-id", "1")
+	vip.Set("github-api-url", server.URL)
+	vip.Set("github-retries", "0")
+	return DiagnoseAuth(vip, dummyRepo{"org", "repo"}, map[string]string{"checks": "write"})
+}
+
+func stepStatuses(d AuthDiagnosis) map[string]string {
+	statuses := map[string]string{}
+	for _, step := range d.Steps {
+		statuses[step.Name] = step.Status
+	}
+	return statuses
+}
+
+func TestDiagnoseAuth_OK(t *testing.T) {
+	d := diagnoseWith(t, `{"checks": "write", "metadata": "read"}`, 200)
+	require.NoError(t, d.Err())
+	assert.Equal(t, map[string]string{
+		"private key":  "ok",
+		"app":          "ok",
+		"installation": "ok",
+		"permissions":  "ok",
+		"token":        "ok",
+		"repository":   "ok",
+	}, stepStatuses(d))
+
+	details := map[string]string{}
+	for _, step := range d.Steps {
+		details[step.Name] = step.Detail
+	}
+	assert.Contains(t, details["private key"], "bits")
+	assert.Contains(t, details["app"], "my-checks")
+	assert.Contains(t, details["installation"], "organization org")
+	assert.Contains(t, details["installation"], "selected")
+	assert.Equal(t, "granted checks:write, metadata:read", details["permissions"])
+}
+
+func TestDiagnoseAuth_MissingPermission(t *testing.T) {
+	d := diagnoseWith(t, `{"checks": "read"}`, 200)
+	require.Error(t, d.Err())
+	assert.Contains(t, d.Err().Error(), "checks:write (has read)")
+	assert.Equal(t, "failed", stepStatuses(d)["permissions"])
+	assert.Equal(t, "skipped", stepStatuses(d)["token"])
+}
+
+func TestDiagnoseAuth_NotInstalled(t *testing.T) {
+	d := diagnoseWith(t, `{}`, 404)
+	apiErr := &APIError{}
+	require.True(t, errors.As(d.Err(), &apiErr))
+	assert.Equal(t, 404, apiErr.StatusCode)
+	assert.Equal(t, "failed", stepStatuses(d)["installation"])
+}
+
+func TestDiagnoseAuth_ExplicitToken(t *testing.T) {
+	vip := viper.New()
+	vip.Set("github-token", "token")
+	d := DiagnoseAuth(vip, dummyRepo{"org", "repo"}, nil)
+	require.NoError(t, d.Err())
+	assert.Len(t, d.Steps, 1)
+}
+
+func TestMissingPermissions(t *testing.T) {
+	granted := map[string]string{"checks": "write", "contents": "read"}
+	assert.Empty(t, missingPermissions(granted, map[string]string{"checks": "read"}))
+	assert.Equal(t,
+		[]string{"contents:write (has read)", "pull_requests:read"},
+		missingPermissions(granted, map[string]string{"contents": "write", "pull_requests": "read"}))
+}
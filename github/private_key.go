@@ -0,0 +1,174 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package github
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sirupsen/logrus"
+)
+
+const pemMarker = "-----BEGIN "
+
+const envKeyPrefix = "env:"
+
+// maxKeyPathLength is the length above which a private key value is assumed
+// not to be a path
+const maxKeyPathLength = 255
+
+// stdin is where a private key of "-" is read from, a variable for tests
+var stdin io.Reader = os.Stdin
+
+// stdinKey holds the key read from stdin, since it can only be read once
+// but the key may be needed more than once
+var stdinKey struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+func readStdinKey() ([]byte, error) {
+	stdinKey.once.Do(func() {
+		logrus.Debug("Reading private key from stdin")
+		stdinKey.data, stdinKey.err = ioutil.ReadAll(stdin)
+	})
+	return stdinKey.data, stdinKey.err
+}
+
+// readPrivateKey reads the app's private key from keyInput, which is one of:
+//
+//   - "-", to read the key from stdin
+//   - "env:NAME", to read the key from the NAME environment variable
+//   - the key itself, as PEM or base64 encoded PEM
+//   - the path to a PEM file
+//
+// PEM keys can be PKCS#1 or PKCS#8, and may have their newlines escaped as
+// literal "\n", as they often are when stored as CI secrets.
+func (g githubAuth) readPrivateKey(keyInput string) (*rsa.PrivateKey, error) {
+	data, err := loadPrivateKey(keyInput)
+	if err != nil {
+		return nil, err
+	}
+	return parsePrivateKey(data)
+}
+
+func loadPrivateKey(keyInput string) ([]byte, error) {
+	switch {
+	case keyInput == "-":
+		data, err := readStdinKey()
+		if err != nil {
+			return nil, fmt.Errorf("error reading private key from stdin: %w", err)
+		}
+		return decodeKeyValue(string(data), "stdin")
+	case strings.HasPrefix(keyInput, envKeyPrefix):
+		name := strings.TrimPrefix(keyInput, envKeyPrefix)
+		logrus.WithField("name", name).Debug("Reading private key from environment variable")
+		value := os.Getenv(name)
+		if value == "" {
+			return nil, fmt.Errorf("environment variable %s with the private key is empty or unset", name)
+		}
+		return decodeKeyValue(value, "environment variable "+name)
+	case strings.Contains(keyInput, pemMarker):
+		return decodeKeyValue(keyInput, "private key value")
+	}
+
+	logrus.WithField("path", keyInput).Debug("Reading private key from file")
+	data, err := ioutil.ReadFile(keyInput)
+	if err == nil {
+		return data, nil
+	}
+	// Not a readable file, but it may be a base64 encoded key
+	if decoded, ok := decodeBase64Key(keyInput); ok {
+		return decoded, nil
+	}
+	// Don't include what might be a mangled key in errors
+	if len(keyInput) > maxKeyPathLength || strings.ContainsAny(keyInput, "\r\n") {
+		return nil, errors.New("private key is not PEM, base64 encoded PEM or the path to a file")
+	}
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("private key file %s does not exist", keyInput)
+	}
+	return nil, fmt.Errorf("error reading private key file: %w", err)
+}
+
+// decodeKeyValue returns the PEM key in value, which may be base64 encoded
+func decodeKeyValue(value string, source string) ([]byte, error) {
+	if strings.Contains(value, pemMarker) {
+		return []byte(value), nil
+	}
+	if decoded, ok := decodeBase64Key(value); ok {
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("private key from %s is neither PEM nor base64 encoded PEM: %w", source, jwt.ErrKeyMustBePEMEncoded)
+}
+
+func decodeBase64Key(value string) ([]byte, bool) {
+	compact := strings.Join(strings.Fields(value), "")
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := encoding.DecodeString(compact)
+		if err == nil && strings.Contains(string(decoded), pemMarker) {
+			logrus.Debug("Decoded base64 encoded private key")
+			return decoded, true
+		}
+	}
+	return nil, false
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	data = []byte(strings.Replace(string(data), `\n`, "\n", -1))
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in private key: %w", jwt.ErrKeyMustBePEMEncoded)
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block) {
+		return nil, errors.New("private key is encrypted, use the unencrypted key downloaded from GitHub")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS#1 private key: %w", err)
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS#8 private key: %w", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is a %T, GitHub apps use RSA keys", key)
+		}
+		return rsaKey, nil
+	}
+	return nil, fmt.Errorf("expected an RSA private key, got a PEM %q block", block.Type)
+}
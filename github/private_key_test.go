@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package github
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKeys(t *testing.T) (*rsa.PrivateKey, string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes})
+	return key, string(pkcs1), string(pkcs8)
+}
+
+func wrapLines(s string, width int) string {
+	lines := []string{}
+	for len(s) > width {
+		lines = append(lines, s[:width])
+		s = s[width:]
+	}
+	return strings.Join(append(lines, s), "\n")
+}
+
+func TestReadPrivateKey_Formats(t *testing.T) {
+	key, pkcs1, pkcs8 := testKeys(t)
+	os.Setenv("CHECKBRIDGE_TEST_KEY", base64.StdEncoding.EncodeToString([]byte(pkcs8)))
+	defer os.Unsetenv("CHECKBRIDGE_TEST_KEY")
+
+	inputs := map[string]string{
+		"pkcs1":          pkcs1,
+		"pkcs8":          pkcs8,
+		"base64":         base64.StdEncoding.EncodeToString([]byte(pkcs1)),
+		"wrapped base64": wrapLines(base64.StdEncoding.EncodeToString([]byte(pkcs8)), 76),
+		"escaped":        strings.Replace(pkcs8, "\n", `\n`, -1),
+		"env":            "env:CHECKBRIDGE_TEST_KEY",
+	}
+	for name, input := range inputs {
+		parsed, err := githubAuth{}.readPrivateKey(input)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, key.N, parsed.N, name)
+		}
+	}
+}
+
+func TestReadPrivateKey_Stdin(t *testing.T) {
+	key, _, pkcs8 := testKeys(t)
+	stdin = strings.NewReader(pkcs8)
+	defer func() { stdin = os.Stdin }()
+
+	// The key can be needed more than once, but stdin only read once
+	for i := 0; i < 2; i++ {
+		parsed, err := githubAuth{}.readPrivateKey("-")
+		require.NoError(t, err)
+		assert.Equal(t, key.N, parsed.N)
+	}
+}
+
+func TestReadPrivateKey_File(t *testing.T) {
+	parsed, err := githubAuth{}.readPrivateKey(testPrivateKey)
+	require.NoError(t, err)
+	assert.NotNil(t, parsed)
+}
+
+func TestReadPrivateKey_Errors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecBytes, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	ecPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecBytes})
+
+	key, _, _ := testKeys(t)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+
+	cases := map[string]string{
+		"missing/key.pem":             "does not exist",
+		"env:CHECKBRIDGE_UNSET_KEY":   "CHECKBRIDGE_UNSET_KEY",
+		string(ecPEM):                 "RSA",
+		string(publicPEM):             "RSA PUBLIC KEY",
+		rsaHeader + "\ngarbage\n":     "Invalid Key",
+		strings.Repeat("notakey", 40): "not PEM",
+	}
+	for input, expected := range cases {
+		_, err := githubAuth{}.readPrivateKey(input)
+		if assert.Error(t, err, input) {
+			assert.Contains(t, err.Error(), expected, input)
+		}
+	}
+}
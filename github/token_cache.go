@@ -0,0 +1,146 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// tokenExpiryMargin is how long before expiring cached tokens stop being
+	// used, so a token doesn't expire while checkbridge is still running
+	tokenExpiryMargin = 5 * time.Minute
+
+	// lockTimeout is how long to wait for another process to release the lock
+	lockTimeout = 30 * time.Second
+	// staleLockAge is the age after which a lock is assumed to have been left
+	// behind by a process which died
+	staleLockAge     = time.Minute
+	lockPollInterval = 50 * time.Millisecond
+)
+
+// tokenCache stores access tokens in a file, so parallel and following
+// checkbridge runs can share them
+type tokenCache struct {
+	path string
+}
+
+// lock takes an exclusive lock on the cache, returning a function releasing
+// it. The lock is a separate file created exclusively, which works the same
+// on every platform.
+func (c tokenCache) lock() (func(), error) {
+	lockPath := c.path + ".lock"
+	deadline := now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() {
+				if err := os.Remove(lockPath); err != nil {
+					logrus.WithError(err).Warn("Could not remove token cache lock")
+				}
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error creating token cache lock: %w", err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && now().Sub(info.ModTime()) > staleLockAge {
+			logrus.WithField("path", lockPath).Warn("Removing stale token cache lock")
+			os.Remove(lockPath)
+			continue
+		}
+		if now().After(deadline) {
+			return nil, errors.New("timed out waiting for token cache lock " + lockPath)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+func (c tokenCache) read() (map[string]Token, error) {
+	tokens := map[string]Token{}
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("error reading token cache %s: %w", c.path, err)
+	}
+	return tokens, nil
+}
+
+// get returns the token cached under key, unless it is about to expire
+func (c tokenCache) get(key string) (Token, bool) {
+	tokens, err := c.read()
+	if err != nil {
+		logrus.WithError(err).Warn("Ignoring unreadable token cache")
+		return Token{}, false
+	}
+	token, ok := tokens[key]
+	if !ok || token.ExpiresAt.Sub(now()) < tokenExpiryMargin {
+		return Token{}, false
+	}
+	return token, true
+}
+
+// put caches token under key, dropping any expired tokens
+func (c tokenCache) put(key string, token Token) error {
+	tokens, err := c.read()
+	if err != nil {
+		tokens = map[string]Token{}
+	}
+	for k, cached := range tokens {
+		if cached.ExpiresAt.Before(now()) {
+			delete(tokens, k)
+		}
+	}
+	tokens[key] = token
+
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so readers never see partial writes;
+	// it's created readable only by the current user
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
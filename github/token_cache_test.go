@@ -0,0 +1,189 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package github
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withTokenCache(t *testing.T, test func(tokenCache)) {
+	dir, err := ioutil.TempDir("", "checkbridge-tokens")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	test(tokenCache{path: filepath.Join(dir, "tokens.json")})
+}
+
+func TestTokenCache_GetPut(t *testing.T) {
+	withTokenCache(t, func(cache tokenCache) {
+		_, ok := cache.get("key")
+		assert.False(t, ok)
+
+		require.NoError(t, cache.put("key", Token{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+		require.NoError(t, cache.put("soon", Token{Token: "old", ExpiresAt: time.Now().Add(time.Minute)}))
+
+		token, ok := cache.get("key")
+		assert.True(t, ok)
+		assert.Equal(t, "token", token.Token)
+
+		_, ok = cache.get("soon")
+		assert.False(t, ok, "token about to expire should not be reused")
+
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(cache.path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+	})
+}
+
+func TestTokenCache_DropsExpired(t *testing.T) {
+	withTokenCache(t, func(cache tokenCache) {
+		require.NoError(t, cache.put("expired", Token{Token: "old", ExpiresAt: time.Now().Add(-time.Minute)}))
+		require.NoError(t, cache.put("key", Token{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+
+		tokens, err := cache.read()
+		require.NoError(t, err)
+		assert.Len(t, tokens, 1)
+	})
+}
+
+func TestTokenCache_Corrupt(t *testing.T) {
+	withTokenCache(t, func(cache tokenCache) {
+		require.NoError(t, ioutil.WriteFile(cache.path, []byte("not json"), 0600))
+		_, ok := cache.get("key")
+		assert.False(t, ok)
+
+		require.NoError(t, cache.put("key", Token{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+		_, ok = cache.get("key")
+		assert.True(t, ok)
+	})
+}
+
+func TestTokenCache_Lock(t *testing.T) {
+	withTokenCache(t, func(cache tokenCache) {
+		unlock, err := cache.lock()
+		require.NoError(t, err)
+
+		acquired := make(chan bool)
+		go func() {
+			unlockAgain, err := cache.lock()
+			if err == nil {
+				unlockAgain()
+			}
+			acquired <- err == nil
+		}()
+
+		select {
+		case <-acquired:
+			assert.Fail(t, "lock acquired twice")
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		unlock()
+		assert.True(t, <-acquired)
+	})
+}
+
+func TestTokenCache_StaleLock(t *testing.T) {
+	withTokenCache(t, func(cache tokenCache) {
+		lockPath := cache.path + ".lock"
+		require.NoError(t, ioutil.WriteFile(lockPath, nil, 0600))
+		old := time.Now().Add(-2 * staleLockAge)
+		require.NoError(t, os.Chtimes(lockPath, old, old))
+
+		unlock, err := cache.lock()
+		require.NoError(t, err)
+		unlock()
+
+		_, err = os.Stat(lockPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestCacheKey(t *testing.T) {
+	vip := viper.New()
+	vip.Set("application-id", "12")
+	g := githubAuth{config: vip}
+	repo := dummyRepo{"org", "repo"}
+	request := TokenRequest{
+		Permissions: map[string]string{"checks": "write", "contents": "read"},
+	}
+
+	assert.Equal(t, "app=12;repo=org/repo;perms=checks:write,contents:read", g.cacheKey(repo, request))
+
+	vip.Set("installation-id", "34")
+	assert.Equal(t, "app=12;installation=34;perms=checks:write,contents:read", g.cacheKey(repo, request))
+
+	request.Repositories = []string{"repo", "other"}
+	assert.Equal(t, "app=12;installation=34;perms=checks:write,contents:read;repos=other,repo", g.cacheKey(repo, request))
+}
+
+func TestGetToken_Cached(t *testing.T) {
+	withTokenCache(t, func(cache tokenCache) {
+		requests := 0
+		expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if strings.HasSuffix(r.URL.Path, "/access_tokens") {
+				w.WriteHeader(201)
+				w.Write([]byte(`{"token": "cached.token", "expires_at": "` + expires + `"}`))
+			} else {
+				w.Write([]byte(`{"id": 42}`))
+			}
+		})
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		vip := viper.New()
+		vip.Set("private-key", testPrivateKey)
+		vip.Set("application-id", "my-id")
+		vip.Set("token-cache", cache.path)
+		gh := githubAuth{
+			config:  vip,
+			apiBase: server.URL,
+		}
+
+		for i := 0; i < 3; i++ {
+			token, err := gh.GetToken(dummyRepo{"org", "repo"}, defaultTestPerms)
+			require.NoError(t, err)
+			assert.Equal(t, "cached.token", token)
+		}
+		assert.Equal(t, 2, requests, "only the first call should hit GitHub")
+
+		_, err := gh.GetToken(dummyRepo{"org", "other"}, defaultTestPerms)
+		require.NoError(t, err)
+		assert.Equal(t, 4, requests, "other repositories need their own token")
+	})
+}
+
+var defaultTestPerms = map[string]string{"checks": "write"}
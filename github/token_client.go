@@ -23,8 +23,11 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 )
 
+// tokenAuthHeaders opt in to the GitHub Apps API preview, still required by
+// GitHub Enterprise Server releases
 var tokenAuthHeaders = map[string]string{
 	"Accept": "application/vnd.github.machine-man-preview+json",
 }
@@ -36,8 +39,13 @@ type tokenClient struct {
 }
 
 type installationResponse struct {
-	ID          int               `json:"id"`
-	Permissions map[string]string `json:"permissions"`
+	ID      int `json:"id"`
+	Account struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"account"`
+	RepositorySelection string            `json:"repository_selection"`
+	Permissions         map[string]string `json:"permissions"`
 }
 
 func (t tokenClient) installationID(r Repo) (string, error) {
@@ -45,7 +53,7 @@ func (t tokenClient) installationID(r Repo) (string, error) {
 	installation := installationResponse{}
 	resp, err := t.getJSON(url, tokenAuthHeaders, &installation)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("error looking up app installation for %s/%s: %w", r.Owner(), r.Name(), err)
 	}
 
 	if resp.StatusCode != 200 {
@@ -65,32 +73,35 @@ func (t tokenClient) accessTokenURL(installationID string) string {
 
 type accessTokenResponse struct {
 	Token       string            `json:"token"`
+	ExpiresAt   time.Time         `json:"expires_at"`
 	Permissions map[string]string `json:"permissions"`
 }
 
 type accessTokenRequest struct {
-	Permissions map[string]string `json:"permissions"`
+	Permissions  map[string]string `json:"permissions,omitempty"`
+	Repositories []string          `json:"repositories,omitempty"`
 }
 
-func (t tokenClient) getAccesssToken(installationID string, perms map[string]string) (string, error) {
+func (t tokenClient) getAccesssToken(installationID string, request TokenRequest) (accessTokenResponse, error) {
 	requestData := accessTokenRequest{
-		Permissions: perms,
+		Permissions:  request.Permissions,
+		Repositories: request.Repositories,
 	}
 
 	tokenResp := accessTokenResponse{}
 
 	resp, err := t.postJSON(t.accessTokenURL(installationID), requestData, tokenAuthHeaders, &tokenResp)
 	if err != nil {
-		return "", err
+		return tokenResp, fmt.Errorf("error creating access token for installation %s: %w", installationID, err)
 	}
 
 	if resp.StatusCode != 201 {
-		return "", fmt.Errorf("non-201 status code %s", resp.Status)
+		return tokenResp, fmt.Errorf("non-201 status code %s", resp.Status)
 	}
 
 	if tokenResp.Token == "" {
-		return "", errors.New("no token in response")
+		return tokenResp, errors.New("no token in response")
 	}
 
-	return tokenResp.Token, nil
+	return tokenResp, nil
 }
@@ -22,6 +22,7 @@ package github
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -104,7 +105,7 @@ func TestGetAccessToken_OK(t *testing.T) {
 	token := "v1.1234"
 	handle := func(w http.ResponseWriter) {
 		w.WriteHeader(201)
-		w.Write([]byte(`{"token": "` + token + `"}`))
+		w.Write([]byte(`{"token": "` + token + `", "expires_at": "2020-06-01T12:00:00Z"}`))
 	}
 	withResponse(t, handle, func(c client) {
 		tc := tokenClient{
@@ -112,8 +113,9 @@ func TestGetAccessToken_OK(t *testing.T) {
 			jwt:    "jwt",
 		}
 
-		token, err := tc.getAccesssToken("fake-installation-id", nil)
+		resp, err := tc.getAccesssToken("fake-installation-id", TokenRequest{})
 		require.NoError(t, err)
-		assert.Equal(t, token, token)
+		assert.Equal(t, token, resp.Token)
+		assert.Equal(t, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC), resp.ExpiresAt)
 	})
 }
@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkstyleError is an <error> element of a Checkstyle XML report
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+type checkstyle struct {
+	reader io.Reader
+}
+
+// NewCheckstyle instantiates a parser for Checkstyle XML reports from a reader
+func NewCheckstyle(reader io.Reader) Parser {
+	return checkstyle{
+		reader: reader,
+	}
+}
+
+func (c checkstyle) Run() (Result, error) {
+	// Reports for large projects can be big, so they're read element by element
+	decoder := xml.NewDecoder(c.reader)
+	annotations := []Annotation{}
+	path := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("decode Checkstyle XML: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "file":
+				path = relativePath(xmlAttr(element, "name"))
+			case "error":
+				e := checkstyleError{}
+				if err := decoder.DecodeElement(&e, &element); err != nil {
+					return Result{}, fmt.Errorf("decode Checkstyle error in %s: %w", path, err)
+				}
+				if a, ok := e.annotation(path); ok {
+					annotations = append(annotations, a)
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Local == "file" {
+				path = ""
+			}
+		}
+	}
+
+	return Result{
+		Annotations: annotations,
+	}, nil
+}
+
+func (e checkstyleError) annotation(path string) (Annotation, bool) {
+	severity := strings.ToLower(e.Severity)
+	if severity == "ignore" {
+		logrus.WithField("source", e.Source).Debugf("Skipping ignored Checkstyle error in %s", path)
+		return Annotation{}, false
+	}
+	if path == "" {
+		logrus.WithField("source", e.Source).Debug("Skipping Checkstyle error outside of a file")
+		return Annotation{}, false
+	}
+
+	level, err := ParseLevel(severity)
+	if err != nil {
+		// Checkstyle's default severity is warning
+		level = LevelWarning
+	}
+
+	a := Annotation{
+		Path:     path,
+		Level:    level,
+		Severity: e.Severity,
+		Title:    e.Source,
+		Message:  e.Message,
+	}
+	a.Line, a.EndLine, a.Column, a.EndColumn = annotationRange(e.Line, e.Line, e.Column, 0)
+	return a, true
+}
+
+// xmlAttr returns the value of an element's attribute, or "" if it isn't set
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCheckstyle = `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="8.0">
+  <file name="src/main/java/App.java">
+    <error line="12" column="5" severity="error" message="Missing a Javadoc comment." source="com.puppycrawl.tools.checkstyle.checks.javadoc.MissingJavadocMethodCheck"/>
+    <error line="20" severity="warning" message="Line is longer than 100 characters (found 112)." source="com.puppycrawl.tools.checkstyle.checks.sizes.LineLengthCheck"/>
+  </file>
+  <file name="src/main/java/Empty.java">
+  </file>
+  <file name="Dockerfile">
+    <error line="3" column="1" severity="info" message="Delete the apt-get lists after installing something" source="DL3009"/>
+    <error line="7" column="1" severity="ignore" message="Pin versions in apt get install" source="DL3008"/>
+    <error line="9" severity="Style" message="Use WORKDIR to switch to a directory" source="DL3003"/>
+  </file>
+</checkstyle>`
+
+func TestCheckstyle(t *testing.T) {
+	result, err := parser.NewCheckstyle(bytes.NewBufferString(testCheckstyle)).Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, []parser.Annotation{
+		{
+			Path:      "src/main/java/App.java",
+			Line:      12,
+			EndLine:   12,
+			Column:    5,
+			EndColumn: 5,
+			Title:     "com.puppycrawl.tools.checkstyle.checks.javadoc.MissingJavadocMethodCheck",
+			Message:   "Missing a Javadoc comment.",
+			Level:     parser.LevelError,
+			Severity:  "error",
+		},
+		{
+			Path:     "src/main/java/App.java",
+			Line:     20,
+			EndLine:  20,
+			Title:    "com.puppycrawl.tools.checkstyle.checks.sizes.LineLengthCheck",
+			Message:  "Line is longer than 100 characters (found 112).",
+			Level:    parser.LevelWarning,
+			Severity: "warning",
+		},
+		{
+			Path:      "Dockerfile",
+			Line:      3,
+			EndLine:   3,
+			Column:    1,
+			EndColumn: 1,
+			Title:     "DL3009",
+			Message:   "Delete the apt-get lists after installing something",
+			Level:     parser.LevelNotice,
+			Severity:  "info",
+		},
+		{
+			Path:     "Dockerfile",
+			Line:     9,
+			EndLine:  9,
+			Title:    "DL3003",
+			Message:  "Use WORKDIR to switch to a directory",
+			Level:    parser.LevelWarning,
+			Severity: "Style",
+		},
+	}, result.Annotations)
+}
+
+func TestCheckstyle_Empty(t *testing.T) {
+	result, err := parser.NewCheckstyle(bytes.NewBufferString(`<checkstyle version="4.3"></checkstyle>`)).Run()
+	require.NoError(t, err)
+	assert.Empty(t, result.Annotations)
+}
+
+func TestCheckstyle_Invalid(t *testing.T) {
+	_, err := parser.NewCheckstyle(bytes.NewBufferString(`<checkstyle><file name="a.kt"><error line="x"/></file></checkstyle>`)).Run()
+	assert.Error(t, err)
+
+	_, err = parser.NewCheckstyle(bytes.NewBufferString(`<checkstyle><file name="a.kt">`)).Run()
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// eslintFile is a file in the output of `eslint -f json`
+type eslintFile struct {
+	FilePath string          `json:"filePath"`
+	Messages []eslintMessage `json:"messages"`
+}
+
+type eslintMessage struct {
+	RuleID    string `json:"ruleId"`
+	Severity  int    `json:"severity"`
+	Fatal     bool   `json:"fatal"`
+	Message   string `json:"message"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+}
+
+type eslint struct {
+	reader io.Reader
+}
+
+// NewEslint instantiates a parser for `eslint -f json` output from a reader
+func NewEslint(reader io.Reader) Parser {
+	return eslint{
+		reader: reader,
+	}
+}
+
+func (e eslint) Run() (Result, error) {
+	files := []eslintFile{}
+	if err := json.NewDecoder(e.reader).Decode(&files); err != nil {
+		return Result{}, fmt.Errorf("decode eslint JSON: %w", err)
+	}
+
+	annotations := []Annotation{}
+	for _, file := range files {
+		path := relativePath(file.FilePath)
+		for _, message := range file.Messages {
+			annotations = append(annotations, message.annotation(path))
+		}
+	}
+
+	return Result{
+		Annotations: annotations,
+	}, nil
+}
+
+func (m eslintMessage) annotation(path string) Annotation {
+	a := Annotation{
+		Path:     path,
+		Level:    LevelWarning,
+		Severity: "warn",
+		Message:  m.Message,
+	}
+	switch {
+	case m.Fatal:
+		// Files eslint couldn't parse at all
+		a.Level = LevelError
+		a.Severity = "fatal"
+	case m.Severity == 2:
+		a.Level = LevelError
+		a.Severity = "error"
+	}
+	if m.RuleID != "" {
+		a.Message = fmt.Sprintf("%s: %s", m.RuleID, m.Message)
+	}
+	// eslint end columns are exclusive, like SARIF's
+	a.Line, a.EndLine, a.Column, a.EndColumn = annotationRange(m.Line, m.EndLine, m.Column, m.EndColumn)
+	return a
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEslint = `[
+  {
+    "filePath": "src/app.js",
+    "messages": [
+      {"ruleId": "no-unused-vars", "severity": 2, "message": "'x' is assigned a value but never used.", "line": 3, "column": 7, "endLine": 3, "endColumn": 8},
+      {"ruleId": "quotes", "severity": 1, "message": "Strings must use singlequote.", "line": 5, "column": 13, "endLine": 7, "endColumn": 2}
+    ],
+    "errorCount": 1,
+    "warningCount": 1
+  },
+  {
+    "filePath": "src/clean.js",
+    "messages": [],
+    "errorCount": 0,
+    "warningCount": 0
+  },
+  {
+    "filePath": "src/broken.js",
+    "messages": [
+      {"ruleId": null, "fatal": true, "severity": 2, "message": "Parsing error: Unexpected token }", "line": 10, "column": 1}
+    ],
+    "errorCount": 1,
+    "warningCount": 0
+  }
+]`
+
+func TestEslint(t *testing.T) {
+	result, err := parser.NewEslint(bytes.NewBufferString(testEslint)).Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, []parser.Annotation{
+		{
+			Path:      "src/app.js",
+			Line:      3,
+			EndLine:   3,
+			Column:    7,
+			EndColumn: 7,
+			Message:   "no-unused-vars: 'x' is assigned a value but never used.",
+			Level:     parser.LevelError,
+			Severity:  "error",
+		},
+		{
+			Path:     "src/app.js",
+			Line:     5,
+			EndLine:  7,
+			Message:  "quotes: Strings must use singlequote.",
+			Level:    parser.LevelWarning,
+			Severity: "warn",
+		},
+		{
+			Path:      "src/broken.js",
+			Line:      10,
+			EndLine:   10,
+			Column:    1,
+			EndColumn: 1,
+			Message:   "Parsing error: Unexpected token }",
+			Level:     parser.LevelError,
+			Severity:  "fatal",
+		},
+	}, result.Annotations)
+}
+
+func TestEslint_Empty(t *testing.T) {
+	result, err := parser.NewEslint(bytes.NewBufferString("[]")).Run()
+	require.NoError(t, err)
+	assert.Empty(t, result.Annotations)
+}
+
+func TestEslint_Invalid(t *testing.T) {
+	_, err := parser.NewEslint(bytes.NewBufferString("src/app.js\n  3:7  error  'x' is assigned")).Run()
+	assert.Error(t, err)
+}
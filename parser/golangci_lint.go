@@ -0,0 +1,157 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// golangciReport is the subset of `golangci-lint run --out-format json` used for annotations
+type golangciReport struct {
+	Issues []golangciIssue `json:"Issues"`
+}
+
+type golangciIssue struct {
+	FromLinter string `json:"FromLinter"`
+	Text       string `json:"Text"`
+	Severity   string `json:"Severity"`
+	Pos        struct {
+		Filename string `json:"Filename"`
+		Line     int    `json:"Line"`
+		Column   int    `json:"Column"`
+	} `json:"Pos"`
+	LineRange *struct {
+		From int `json:"From"`
+		To   int `json:"To"`
+	} `json:"LineRange"`
+	Replacement *struct {
+		NeedOnlyDelete bool     `json:"NeedOnlyDelete"`
+		NewLines       []string `json:"NewLines"`
+		Inline         *struct {
+			StartCol  int    `json:"StartCol"`
+			Length    int    `json:"Length"`
+			NewString string `json:"NewString"`
+		} `json:"Inline"`
+	} `json:"Replacement"`
+}
+
+type golangciLint struct {
+	reader       io.Reader
+	defaultLevel Level
+}
+
+// NewGolangciLint instantiates a parser for golangci-lint JSON output from a
+// reader. Issues without a recognized severity are reported at defaultLevel.
+func NewGolangciLint(defaultLevel Level, reader io.Reader) Parser {
+	return golangciLint{
+		reader:       reader,
+		defaultLevel: defaultLevel,
+	}
+}
+
+func (g golangciLint) Run() (Result, error) {
+	report := golangciReport{}
+	if err := json.NewDecoder(g.reader).Decode(&report); err != nil {
+		return Result{}, fmt.Errorf("decode golangci-lint JSON: %w", err)
+	}
+
+	annotations := []Annotation{}
+	counts := map[string]int{}
+	for _, issue := range report.Issues {
+		annotations = append(annotations, g.annotation(issue))
+		counts[issue.FromLinter]++
+	}
+
+	return Result{
+		Annotations: annotations,
+		Summary:     golangciSummary(counts),
+	}, nil
+}
+
+func (g golangciLint) annotation(issue golangciIssue) Annotation {
+	level := g.defaultLevel
+	if issue.Severity != "" {
+		parsed, err := ParseLevel(issue.Severity)
+		if err != nil {
+			logrus.WithError(err).Debugf("Using default level for %s issue", issue.FromLinter)
+		} else {
+			level = parsed
+		}
+	}
+
+	endLine := issue.Pos.Line
+	if issue.LineRange != nil {
+		endLine = issue.LineRange.To
+	}
+
+	a := Annotation{
+		Path:       relativePath(issue.Pos.Filename),
+		Level:      level,
+		Severity:   issue.Severity,
+		Title:      issue.FromLinter,
+		Message:    issue.Text,
+		RawDetails: issue.replacement(),
+	}
+	a.Line, a.EndLine, a.Column, a.EndColumn = annotationRange(issue.Pos.Line, endLine, issue.Pos.Column, 0)
+	return a
+}
+
+// replacement describes the issue's suggested fix, if it has one
+func (issue golangciIssue) replacement() string {
+	r := issue.Replacement
+	switch {
+	case r == nil:
+		return ""
+	case r.Inline != nil:
+		return fmt.Sprintf("Replace %d characters at column %d with:\n%s", r.Inline.Length, r.Inline.StartCol+1, r.Inline.NewString)
+	case r.NeedOnlyDelete:
+		return "Delete these lines"
+	}
+	return fmt.Sprintf("Replace with:\n%s", strings.Join(r.NewLines, "\n"))
+}
+
+// golangciSummary lists the number of issues from each linter, most first
+func golangciSummary(counts map[string]int) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	linters := []string{}
+	for linter := range counts {
+		linters = append(linters, linter)
+	}
+	sort.Slice(linters, func(i, j int) bool {
+		if counts[linters[i]] != counts[linters[j]] {
+			return counts[linters[i]] > counts[linters[j]]
+		}
+		return linters[i] < linters[j]
+	})
+
+	lines := []string{"Issues by linter:", ""}
+	for _, linter := range linters {
+		lines = append(lines, fmt.Sprintf("- %s: %d", linter, counts[linter]))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGolangciLint = `{
+  "Issues": [
+    {
+      "FromLinter": "errcheck",
+      "Text": "Error return value of ` + "`f.Close`" + ` is not checked",
+      "Severity": "",
+      "SourceLines": ["\tf.Close()"],
+      "Replacement": null,
+      "Pos": {"Filename": "cmd/root.go", "Offset": 512, "Line": 42, "Column": 9},
+      "ExpectNoLint": false,
+      "ExpectedNoLintLinter": ""
+    },
+    {
+      "FromLinter": "gofmt",
+      "Text": "File is not ` + "`gofmt`" + `-ed with ` + "`-s`" + `",
+      "Severity": "error",
+      "SourceLines": ["\tx := []int{int(1)}", "\ty := 2"],
+      "Replacement": {"NeedOnlyDelete": false, "NewLines": ["\tx := []int{1}", "\ty := 2"]},
+      "LineRange": {"From": 10, "To": 11},
+      "Pos": {"Filename": "parser/result.go", "Offset": 0, "Line": 10, "Column": 0}
+    },
+    {
+      "FromLinter": "errcheck",
+      "Text": "Error return value is not checked",
+      "Severity": "info",
+      "Replacement": {"NeedOnlyDelete": false, "NewLines": null, "Inline": {"StartCol": 1, "Length": 3, "NewString": "_ = f"}},
+      "Pos": {"Filename": "main.go", "Offset": 0, "Line": 5, "Column": 2}
+    },
+    {
+      "FromLinter": "misspell",
+      "Text": "` + "`recieve`" + ` is a misspelling of ` + "`receive`" + `",
+      "Severity": "major",
+      "Pos": {"Filename": "README.md", "Offset": 0, "Line": 3, "Column": 5}
+    }
+  ],
+  "Report": {"Linters": [{"Name": "errcheck", "Enabled": true}]}
+}`
+
+func TestGolangciLint(t *testing.T) {
+	result, err := parser.NewGolangciLint(parser.LevelWarning, bytes.NewBufferString(testGolangciLint)).Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, []parser.Annotation{
+		{
+			Path:      "cmd/root.go",
+			Line:      42,
+			EndLine:   42,
+			Column:    9,
+			EndColumn: 9,
+			Title:     "errcheck",
+			Message:   "Error return value of `f.Close` is not checked",
+			Level:     parser.LevelWarning,
+		},
+		{
+			Path:       "parser/result.go",
+			Line:       10,
+			EndLine:    11,
+			Title:      "gofmt",
+			Message:    "File is not `gofmt`-ed with `-s`",
+			Level:      parser.LevelError,
+			Severity:   "error",
+			RawDetails: "Replace with:\n\tx := []int{1}\n\ty := 2",
+		},
+		{
+			Path:       "main.go",
+			Line:       5,
+			EndLine:    5,
+			Column:     2,
+			EndColumn:  2,
+			Title:      "errcheck",
+			Message:    "Error return value is not checked",
+			Level:      parser.LevelNotice,
+			Severity:   "info",
+			RawDetails: "Replace 3 characters at column 2 with:\n_ = f",
+		},
+		{
+			Path:      "README.md",
+			Line:      3,
+			EndLine:   3,
+			Column:    5,
+			EndColumn: 5,
+			Title:     "misspell",
+			Message:   "`recieve` is a misspelling of `receive`",
+			Level:     parser.LevelWarning,
+			Severity:  "major",
+		},
+	}, result.Annotations)
+
+	assert.Equal(t, "Issues by linter:\n\n- errcheck: 2\n- gofmt: 1\n- misspell: 1", result.Summary)
+}
+
+func TestGolangciLint_DefaultLevel(t *testing.T) {
+	result, err := parser.NewGolangciLint(parser.LevelError, bytes.NewBufferString(testGolangciLint)).Run()
+	require.NoError(t, err)
+
+	require.Len(t, result.Annotations, 4)
+	assert.Equal(t, parser.LevelError, result.Annotations[0].Level)
+	assert.Equal(t, parser.LevelNotice, result.Annotations[2].Level)
+	assert.Equal(t, parser.LevelError, result.Annotations[3].Level)
+}
+
+func TestGolangciLint_NoIssues(t *testing.T) {
+	result, err := parser.NewGolangciLint(parser.LevelWarning, bytes.NewBufferString(`{"Issues": null, "Report": {}}`)).Run()
+	require.NoError(t, err)
+	assert.Empty(t, result.Annotations)
+	assert.Empty(t, result.Summary)
+}
+
+func TestGolangciLint_Invalid(t *testing.T) {
+	_, err := parser.NewGolangciLint(parser.LevelWarning, bytes.NewBufferString("main.go:5:2: Error return value is not checked (errcheck)")).Run()
+	assert.Error(t, err)
+}
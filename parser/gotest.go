@@ -0,0 +1,364 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// goTestLocationRegex finds test file locations in test output, such as
+	// "    app_test.go:12: got 1" or "\t/src/app/app_test.go:12 +0x1d" in a panic
+	goTestLocationRegex = regexp.MustCompile(`(?:^|[\s/\\])(\w[\w.-]*_test\.go):(\d+)`)
+	// goBuildErrorRegex matches compiler and vet errors, such as "./app.go:12:3: undefined: x"
+	goBuildErrorRegex = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+	goModuleRegex     = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+)
+
+// goTestEvent is an event from `go test -json`, see `go doc test2json`
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// goTestRun is the output and outcome of a package or a test in it
+type goTestRun struct {
+	pkg     string
+	test    string
+	action  string
+	elapsed float64
+	output  []string
+}
+
+type goTest struct {
+	reader io.Reader
+}
+
+// NewGoTest instantiates a parser for `go test -json` output from a reader
+func NewGoTest(reader io.Reader) Parser {
+	return goTest{
+		reader: reader,
+	}
+}
+
+func (g goTest) Run() (Result, error) {
+	packages := []*goTestRun{}
+	tests := []*goTestRun{}
+	runs := map[string]*goTestRun{}
+	build := []string{}
+
+	scanner := bufio.NewScanner(g.reader)
+	// Tests can print long lines
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		event := goTestEvent{}
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+			// Build failures are printed to stderr as plain text, which is
+			// usually redirected to the same output
+			build = append(build, line)
+			continue
+		}
+
+		switch event.Action {
+		case "build-output":
+			build = append(build, strings.TrimSuffix(event.Output, "\n"))
+			continue
+		case "output", "pass", "fail", "skip":
+		default:
+			continue
+		}
+
+		key := event.Package + " " + event.Test
+		run, ok := runs[key]
+		if !ok {
+			run = &goTestRun{pkg: event.Package, test: event.Test}
+			runs[key] = run
+			if event.Test == "" {
+				packages = append(packages, run)
+			} else {
+				tests = append(tests, run)
+			}
+		}
+		if event.Action == "output" {
+			run.output = append(run.output, strings.TrimSuffix(event.Output, "\n"))
+		} else {
+			run.action = event.Action
+			run.elapsed = event.Elapsed
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Result{}, fmt.Errorf("read go test output: %w", err)
+	}
+
+	module := findGoModule()
+	counts := goTestCounts{}
+	failedPackages := map[string]bool{}
+	for _, pkg := range packages {
+		counts.elapsed += time.Duration(pkg.elapsed * float64(time.Second))
+		if pkg.action == "fail" {
+			failedPackages[pkg.pkg] = true
+		}
+	}
+
+	annotations := []Annotation{}
+	unlocated := []string{}
+	failingTests := map[string]bool{}
+	for _, test := range tests {
+		if test.action == "" && failedPackages[test.pkg] {
+			// Tests that never finished were interrupted by a panic or timeout
+			test.action = "fail"
+		}
+		counts.add(test.action)
+		if test.action != "fail" {
+			continue
+		}
+		failingTests[test.pkg] = true
+		if test.hasFailingSubtest(tests) {
+			// The subtest is annotated instead
+			continue
+		}
+		if a, ok := test.annotation(module); ok {
+			annotations = append(annotations, a)
+		} else {
+			unlocated = append(unlocated, fmt.Sprintf("- %s.%s: %s", test.pkg, test.test, test.message()))
+		}
+	}
+
+	buildErrors, buildOutput := goBuildErrors(build)
+	annotations = append(annotations, buildErrors...)
+	for _, pkg := range packages {
+		// Failures outside of any test, such as in TestMain or init
+		if pkg.action != "fail" || failingTests[pkg.pkg] || pkg.buildFailed() {
+			continue
+		}
+		if a, ok := pkg.annotation(module); ok {
+			annotations = append(annotations, a)
+		} else {
+			unlocated = append(unlocated, fmt.Sprintf("- %s: %s", pkg.pkg, pkg.message()))
+		}
+	}
+
+	summary := counts.String()
+	if len(unlocated) > 0 {
+		summary = appendParagraph(summary, "Failures without a location:\n\n"+strings.Join(unlocated, "\n"))
+	}
+	if len(buildOutput) > 0 {
+		summary = appendParagraph(summary, "Build output:\n\n```\n"+strings.Join(buildOutput, "\n")+"\n```")
+	}
+
+	title := counts.title()
+	if counts.failed == 0 {
+		if len(buildErrors) > 0 {
+			title = "Build failed"
+		} else if len(failedPackages) > 0 {
+			title = fmt.Sprintf("%d of %d packages failed", len(failedPackages), len(packages))
+		}
+	}
+	return Result{
+		Annotations: annotations,
+		Title:       title,
+		Summary:     summary,
+		Failed:      len(failedPackages) > 0 || counts.failed > 0 || len(buildErrors) > 0,
+	}, nil
+}
+
+func (r *goTestRun) hasFailingSubtest(tests []*goTestRun) bool {
+	for _, test := range tests {
+		if test.pkg == r.pkg && test.action == "fail" && strings.HasPrefix(test.test, r.test+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *goTestRun) buildFailed() bool {
+	for _, line := range r.output {
+		if strings.HasSuffix(line, "[build failed]") || strings.HasSuffix(line, "[setup failed]") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *goTestRun) annotation(module goModule) (Annotation, bool) {
+	output := strings.Join(r.output, "\n")
+	match := goTestLocationRegex.FindStringSubmatch(output)
+	if match == nil {
+		return Annotation{}, false
+	}
+	line, err := strconv.Atoi(match[2])
+	if err != nil {
+		return Annotation{}, false
+	}
+
+	title := r.test
+	if title == "" {
+		title = r.pkg
+	}
+	return Annotation{
+		Path:       module.path(r.pkg, match[1]),
+		Line:       line,
+		EndLine:    line,
+		Title:      title,
+		Message:    r.message(),
+		Level:      LevelError,
+		Severity:   "fail",
+		RawDetails: strings.TrimSpace(output),
+	}, true
+}
+
+// message is the output of a failed test or package, without the lines
+// `go test` adds around it
+func (r *goTestRun) message() string {
+	lines := []string{}
+	for _, line := range r.output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "FAIL" || trimmed == "PASS" ||
+			strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") ||
+			strings.HasPrefix(trimmed, "FAIL\t") || strings.HasPrefix(trimmed, "ok  \t") ||
+			strings.HasPrefix(trimmed, "exit status ") {
+			continue
+		}
+		// Panics are followed by long goroutine traces, which are kept in the details
+		if strings.HasPrefix(trimmed, "panic: ") {
+			lines = append(lines, trimmed)
+			break
+		}
+		lines = append(lines, trimmed)
+	}
+	if len(lines) == 0 {
+		return "failed"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goBuildErrors finds compiler errors in build output, returning the
+// remaining output that couldn't be annotated
+func goBuildErrors(build []string) ([]Annotation, []string) {
+	annotations := []Annotation{}
+	other := []string{}
+	for _, line := range build {
+		match := goBuildErrorRegex.FindStringSubmatch(line)
+		if match == nil {
+			// Package headers such as "# example.com/app" only group errors
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "# ") {
+				other = append(other, line)
+			}
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		a := Annotation{
+			Path:     relativePath(match[1]),
+			Title:    "build failed",
+			Message:  match[4],
+			Level:    LevelError,
+			Severity: "build",
+		}
+		a.Line, a.EndLine, a.Column, a.EndColumn = annotationRange(lineNumber, lineNumber, column, 0)
+		annotations = append(annotations, a)
+	}
+	return annotations, other
+}
+
+type goTestCounts struct {
+	passed  int
+	failed  int
+	skipped int
+	elapsed time.Duration
+}
+
+func (c *goTestCounts) add(action string) {
+	switch action {
+	case "pass":
+		c.passed++
+	case "fail":
+		c.failed++
+	case "skip":
+		c.skipped++
+	}
+}
+
+func (c goTestCounts) title() string {
+	tests := c.passed + c.failed + c.skipped
+	if c.failed > 0 {
+		return fmt.Sprintf("%d of %s failed", c.failed, countTests(tests))
+	}
+	return fmt.Sprintf("%s passed", countTests(c.passed))
+}
+
+func (c goTestCounts) String() string {
+	return fmt.Sprintf(
+		"%s: %d passed, %d failed, %d skipped in %s",
+		countTests(c.passed+c.failed+c.skipped), c.passed, c.failed, c.skipped, c.elapsed.Round(time.Millisecond),
+	)
+}
+
+// goModule is the module in or above the working directory, used to find
+// the directory of a package
+type goModule struct {
+	root string
+	name string
+}
+
+func findGoModule() goModule {
+	dir, err := os.Getwd()
+	if err != nil {
+		return goModule{}
+	}
+	for {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			if match := goModuleRegex.FindSubmatch(contents); match != nil {
+				return goModule{root: dir, name: string(match[1])}
+			}
+			return goModule{}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return goModule{}
+		}
+		dir = parent
+	}
+}
+
+// path converts a file name in a package to a path relative to the working directory
+func (m goModule) path(pkg string, file string) string {
+	if m.name == "" || (pkg != m.name && !strings.HasPrefix(pkg, m.name+"/")) {
+		// The package's directory is unknown, so only the file name can be reported
+		return file
+	}
+	dir := filepath.Join(m.root, filepath.FromSlash(strings.TrimPrefix(pkg, m.name)))
+	return relativePath(filepath.Join(dir, file))
+}
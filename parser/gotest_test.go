@@ -0,0 +1,165 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGoTest = `{"Action":"run","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPass"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPass","Output":"--- PASS: TestPass (0.00s)\n"}
+{"Action":"pass","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPass","Elapsed":0}
+{"Action":"run","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable","Output":"=== RUN   TestTable\n"}
+{"Action":"run","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable/empty"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable/empty","Output":"=== RUN   TestTable/empty\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable/empty","Output":"    gotest_test.go:42: got 1, want 0\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable/empty","Output":"        extra detail\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable/empty","Output":"    --- FAIL: TestTable/empty (0.00s)\n"}
+{"Action":"fail","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable/empty","Elapsed":0}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable","Output":"--- FAIL: TestTable (0.00s)\n"}
+{"Action":"fail","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestTable","Elapsed":0}
+{"Action":"run","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestSkip"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestSkip","Output":"--- SKIP: TestSkip (0.00s)\n"}
+{"Action":"skip","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestSkip","Elapsed":0}
+{"Action":"run","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"=== RUN   TestPanic\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"panic: runtime error: index out of range [1] with length 0 [recovered]\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"goroutine 7 [running]:\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"testing.tRunner.func1.2({0x5f8a40, 0xc000016150})\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"\t/usr/local/go/src/testing/testing.go:1545 +0x238\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"github.com/roverdotcom/checkbridge/parser_test.TestPanic(0xc000007860)\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Test":"TestPanic","Output":"\t/home/runner/work/checkbridge/parser/gotest_test.go:57 +0x1d\n"}
+{"Action":"output","Package":"github.com/roverdotcom/checkbridge/parser","Output":"FAIL\tgithub.com/roverdotcom/checkbridge/parser\t0.012s\n"}
+{"Action":"fail","Package":"github.com/roverdotcom/checkbridge/parser","Elapsed":0.012}
+{"Action":"run","Package":"example.com/other","Test":"TestTimeout"}
+{"Action":"output","Package":"example.com/other","Test":"TestTimeout","Output":"--- FAIL: TestTimeout (2.00s)\n"}
+{"Action":"output","Package":"example.com/other","Test":"TestTimeout","Output":"    connection refused\n"}
+{"Action":"fail","Package":"example.com/other","Test":"TestTimeout","Elapsed":2}
+{"Action":"output","Package":"example.com/other","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/other","Elapsed":2.5}
+`
+
+func TestGoTest(t *testing.T) {
+	result, err := parser.NewGoTest(bytes.NewBufferString(testGoTest)).Run()
+	require.NoError(t, err)
+
+	require.Len(t, result.Annotations, 2)
+	assert.Equal(t, parser.Annotation{
+		Path:       "gotest_test.go",
+		Line:       42,
+		EndLine:    42,
+		Title:      "TestTable/empty",
+		Message:    "gotest_test.go:42: got 1, want 0\nextra detail",
+		Level:      parser.LevelError,
+		Severity:   "fail",
+		RawDetails: "=== RUN   TestTable/empty\n    gotest_test.go:42: got 1, want 0\n        extra detail\n    --- FAIL: TestTable/empty (0.00s)",
+	}, result.Annotations[0])
+
+	panicked := result.Annotations[1]
+	assert.Equal(t, "gotest_test.go", panicked.Path)
+	assert.Equal(t, 57, panicked.Line)
+	assert.Equal(t, "TestPanic", panicked.Title)
+	assert.Equal(t, "panic: runtime error: index out of range [1] with length 0 [recovered]", panicked.Message)
+	assert.Contains(t, panicked.RawDetails, "goroutine 7 [running]:")
+
+	assert.True(t, result.Failed)
+	assert.Equal(t, "4 of 6 tests failed", result.Title)
+	assert.Equal(t, "6 tests: 1 passed, 4 failed, 1 skipped in 2.512s\n\n"+
+		"Failures without a location:\n\n- example.com/other.TestTimeout: connection refused", result.Summary)
+}
+
+func TestGoTest_Passed(t *testing.T) {
+	output := `{"Action":"run","Package":"example.com/app","Test":"TestOne"}
+{"Action":"pass","Package":"example.com/app","Test":"TestOne","Elapsed":0.01}
+{"Action":"output","Package":"example.com/app","Output":"ok  \texample.com/app\t0.020s\n"}
+{"Action":"pass","Package":"example.com/app","Elapsed":0.02}
+{"Action":"skip","Package":"example.com/app/internal","Elapsed":0}
+`
+	result, err := parser.NewGoTest(bytes.NewBufferString(output)).Run()
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Annotations)
+	assert.False(t, result.Failed)
+	assert.Equal(t, "1 test passed", result.Title)
+	assert.Equal(t, "1 test: 1 passed, 0 failed, 0 skipped in 20ms", result.Summary)
+}
+
+func TestGoTest_BuildFailed(t *testing.T) {
+	output := `# example.com/app
+./app.go:12:3: undefined: missing
+./app.go:20:2: x declared and not used
+note: module requires Go 1.99
+{"Action":"output","Package":"example.com/app","Output":"FAIL\texample.com/app [build failed]\n"}
+{"Action":"fail","Package":"example.com/app","Elapsed":0}
+`
+	result, err := parser.NewGoTest(bytes.NewBufferString(output)).Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, []parser.Annotation{
+		{
+			Path:      "app.go",
+			Line:      12,
+			EndLine:   12,
+			Column:    3,
+			EndColumn: 3,
+			Title:     "build failed",
+			Message:   "undefined: missing",
+			Level:     parser.LevelError,
+			Severity:  "build",
+		},
+		{
+			Path:      "app.go",
+			Line:      20,
+			EndLine:   20,
+			Column:    2,
+			EndColumn: 2,
+			Title:     "build failed",
+			Message:   "x declared and not used",
+			Level:     parser.LevelError,
+			Severity:  "build",
+		},
+	}, result.Annotations)
+	assert.True(t, result.Failed)
+	assert.Equal(t, "Build failed", result.Title)
+	assert.Equal(t, "0 tests: 0 passed, 0 failed, 0 skipped in 0s\n\nBuild output:\n\n```\nnote: module requires Go 1.99\n```", result.Summary)
+}
+
+func TestGoTest_PackageFailed(t *testing.T) {
+	output := `{"Action":"output","Package":"example.com/app","Output":"TestMain: database unavailable\n"}
+{"Action":"output","Package":"example.com/app","Output":"FAIL\texample.com/app\t0.003s\n"}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.003}
+`
+	result, err := parser.NewGoTest(bytes.NewBufferString(output)).Run()
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Annotations)
+	assert.True(t, result.Failed)
+	assert.Equal(t, "1 of 1 packages failed", result.Title)
+	assert.Equal(t, "0 tests: 0 passed, 0 failed, 0 skipped in 3ms\n\n"+
+		"Failures without a location:\n\n- example.com/app: TestMain: database unavailable", result.Summary)
+}
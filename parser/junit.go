@@ -0,0 +1,284 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// junitLocationRegex finds file:line locations in stack traces, such as
+// "tests/test_app.py:12: AssertionError" or "at Object.<anonymous> (src/app.test.js:10:5)"
+var junitLocationRegex = regexp.MustCompile(`([\w./\\-]*\w\.\w+):(\d+)`)
+
+// junitTestCase is a <testcase> element of a JUnit XML report
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Line      int            `xml:"line,attr"`
+	Time      string         `xml:"time,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Errors    []junitFailure `xml:"error"`
+	Skipped   *struct{}      `xml:"skipped"`
+}
+
+// junitFailure is a <failure> or <error> of a test case
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+
+	// kind is "failure" for failed assertions or "error" for unexpected errors
+	kind string
+}
+
+type junitCounts struct {
+	tests   int
+	failed  int
+	errored int
+	skipped int
+	elapsed time.Duration
+}
+
+type junit struct {
+	reader io.Reader
+}
+
+// NewJUnit instantiates a parser for JUnit XML test reports from a reader
+func NewJUnit(reader io.Reader) Parser {
+	return junit{
+		reader: reader,
+	}
+}
+
+func (j junit) Run() (Result, error) {
+	decoder := xml.NewDecoder(j.reader)
+	annotations := []Annotation{}
+	unlocated := []string{}
+	counts := junitCounts{}
+	// Some tools set the file on the <testsuite> rather than each test
+	suiteFiles := []string{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("decode JUnit XML: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "testsuite":
+				suiteFiles = append(suiteFiles, xmlAttr(element, "file"))
+			case "testcase":
+				test := junitTestCase{}
+				if err := decoder.DecodeElement(&test, &element); err != nil {
+					return Result{}, fmt.Errorf("decode JUnit test case: %w", err)
+				}
+				if test.File == "" {
+					test.File = innermostFile(suiteFiles)
+				}
+
+				counts.add(test)
+				for _, failure := range test.problems() {
+					if a, ok := test.annotation(failure); ok {
+						annotations = append(annotations, a)
+					} else {
+						unlocated = append(unlocated, fmt.Sprintf("- %s: %s", test.title(), failure.summary()))
+					}
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Local == "testsuite" && len(suiteFiles) > 0 {
+				suiteFiles = suiteFiles[:len(suiteFiles)-1]
+			}
+		}
+	}
+
+	summary := counts.String()
+	if len(unlocated) > 0 {
+		summary = appendParagraph(summary, "Failures without a location:\n\n"+strings.Join(unlocated, "\n"))
+	}
+	return Result{
+		Annotations: annotations,
+		Title:       counts.title(),
+		Summary:     summary,
+		Failed:      counts.failed+counts.errored > 0,
+	}, nil
+}
+
+// problems are the failures and errors of a test case
+func (t junitTestCase) problems() []junitFailure {
+	problems := []junitFailure{}
+	for _, f := range t.Failures {
+		f.kind = "failure"
+		problems = append(problems, f)
+	}
+	for _, e := range t.Errors {
+		e.kind = "error"
+		problems = append(problems, e)
+	}
+	return problems
+}
+
+func (t junitTestCase) title() string {
+	if t.ClassName == "" || strings.HasPrefix(t.Name, t.ClassName) {
+		return t.Name
+	}
+	return fmt.Sprintf("%s.%s", t.ClassName, t.Name)
+}
+
+func (t junitTestCase) annotation(failure junitFailure) (Annotation, bool) {
+	file, line := t.location(failure.Text)
+	if file == "" {
+		return Annotation{}, false
+	}
+
+	return Annotation{
+		Path:       file,
+		Line:       line,
+		EndLine:    line,
+		Title:      t.title(),
+		Message:    failure.summary(),
+		Level:      LevelError,
+		Severity:   failure.kind,
+		RawDetails: strings.TrimSpace(failure.Text),
+	}, true
+}
+
+// location finds where a test failed, from its attributes or stack trace
+func (t junitTestCase) location(trace string) (string, int) {
+	if t.File != "" && t.Line > 0 {
+		return relativePath(t.File), t.Line
+	}
+
+	for _, match := range junitLocationRegex.FindAllStringSubmatch(trace, -1) {
+		file := relativePath(match[1])
+		line, err := strconv.Atoi(match[2])
+		if err != nil || line < 1 {
+			continue
+		}
+		if t.File != "" {
+			// Traces often only have the file's base name, as in Java
+			if path.Base(file) == path.Base(relativePath(t.File)) {
+				return relativePath(t.File), line
+			}
+			continue
+		}
+		if isProjectFile(file) {
+			return file, line
+		}
+	}
+
+	if t.File != "" {
+		return relativePath(t.File), 1
+	}
+	return "", 0
+}
+
+// summary is the first line of a failure's message, or of its text
+func (f junitFailure) summary() string {
+	for _, text := range []string{f.Message, f.Text, f.Type} {
+		text = strings.TrimSpace(text)
+		if text != "" {
+			return strings.SplitN(text, "\n", 2)[0]
+		}
+	}
+	return "failed"
+}
+
+func (c *junitCounts) add(t junitTestCase) {
+	c.tests++
+	switch {
+	case len(t.Errors) > 0:
+		c.errored++
+	case len(t.Failures) > 0:
+		c.failed++
+	case t.Skipped != nil:
+		c.skipped++
+	}
+	if seconds, err := strconv.ParseFloat(strings.ReplaceAll(t.Time, ",", ""), 64); err == nil {
+		c.elapsed += time.Duration(seconds * float64(time.Second))
+	}
+}
+
+func (c junitCounts) title() string {
+	if broken := c.failed + c.errored; broken > 0 {
+		return fmt.Sprintf("%d of %s failed", broken, countTests(c.tests))
+	}
+	return fmt.Sprintf("%s passed", countTests(c.tests-c.skipped))
+}
+
+func (c junitCounts) String() string {
+	passed := c.tests - c.failed - c.errored - c.skipped
+	return fmt.Sprintf(
+		"%s: %d passed, %d failed, %d errored, %d skipped in %s",
+		countTests(c.tests), passed, c.failed, c.errored, c.skipped, c.elapsed.Round(time.Millisecond),
+	)
+}
+
+func countTests(count int) string {
+	if count == 1 {
+		return "1 test"
+	}
+	return fmt.Sprintf("%d tests", count)
+}
+
+// isProjectFile reports whether a path from a stack trace is a file in this
+// project, rather than in the standard library or a dependency
+func isProjectFile(file string) bool {
+	if filepath.IsAbs(filepath.FromSlash(file)) || strings.HasPrefix(file, "../") {
+		return false
+	}
+	for _, dir := range []string{"node_modules", "site-packages", "vendor"} {
+		if strings.Contains("/"+file, "/"+dir+"/") {
+			return false
+		}
+	}
+	info, err := os.Stat(file)
+	return err == nil && !info.IsDir()
+}
+
+func innermostFile(files []string) string {
+	for i := len(files) - 1; i >= 0; i-- {
+		if files[i] != "" {
+			return files[i]
+		}
+	}
+	return ""
+}
+
+func appendParagraph(text string, paragraph string) string {
+	if text == "" {
+		return paragraph
+	}
+	return text + "\n\n" + paragraph
+}
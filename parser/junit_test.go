@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJUnit = `<?xml version="1.0" encoding="utf-8"?>
+<testsuites>
+  <testsuite name="pytest" tests="4" failures="1" errors="1" skipped="1" time="1.5">
+    <testcase classname="tests.test_app" name="test_index" file="tests/test_app.py" line="12" time="0.250"/>
+    <testcase classname="tests.test_app" name="test_create" file="tests/test_app.py" line="20" time="0.500">
+      <failure message="AssertionError: assert 404 == 201">def test_create(client):
+&gt;       assert client.post("/").status_code == 201
+E       AssertionError: assert 404 == 201
+
+tests/test_app.py:22: AssertionError</failure>
+    </testcase>
+    <testcase classname="tests.test_app" name="test_delete" time="0.750">
+      <error message="ConnectionError: refused">Traceback (most recent call last):
+  File "/usr/lib/python3.8/socket.py", line 10, in connect
+junit_test.go:30: ConnectionError</error>
+    </testcase>
+    <testcase classname="tests.test_app" name="test_slow" time="0">
+      <skipped message="slow"/>
+    </testcase>
+  </testsuite>
+  <testsuite name="com.example.AppTest" file="src/test/java/com/example/AppTest.java" time="2">
+    <testcase classname="com.example.AppTest" name="testLogin" time="2">
+      <failure message="expected:&lt;200&gt; but was:&lt;500&gt;" type="org.opentest4j.AssertionFailedError">org.opentest4j.AssertionFailedError: expected:&lt;200&gt; but was:&lt;500&gt;
+	at org.junit.jupiter.api.AssertionUtils.fail(AssertionUtils.java:55)
+	at com.example.AppTest.testLogin(AppTest.java:42)</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="integration">
+    <testcase classname="integration" name="test_checkout" time="0.1">
+      <failure type="TimeoutError">Timed out after 100ms
+	at /opt/runner/lib/runner.js:10:5</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+func TestJUnit(t *testing.T) {
+	result, err := parser.NewJUnit(bytes.NewBufferString(testJUnit)).Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, []parser.Annotation{
+		{
+			Path:       "tests/test_app.py",
+			Line:       20,
+			EndLine:    20,
+			Title:      "tests.test_app.test_create",
+			Message:    "AssertionError: assert 404 == 201",
+			Level:      parser.LevelError,
+			Severity:   "failure",
+			RawDetails: "def test_create(client):\n>       assert client.post(\"/\").status_code == 201\nE       AssertionError: assert 404 == 201\n\ntests/test_app.py:22: AssertionError",
+		},
+		{
+			Path:       "junit_test.go",
+			Line:       30,
+			EndLine:    30,
+			Title:      "tests.test_app.test_delete",
+			Message:    "ConnectionError: refused",
+			Level:      parser.LevelError,
+			Severity:   "error",
+			RawDetails: "Traceback (most recent call last):\n  File \"/usr/lib/python3.8/socket.py\", line 10, in connect\njunit_test.go:30: ConnectionError",
+		},
+		{
+			Path:       "src/test/java/com/example/AppTest.java",
+			Line:       42,
+			EndLine:    42,
+			Title:      "com.example.AppTest.testLogin",
+			Message:    "expected:<200> but was:<500>",
+			Level:      parser.LevelError,
+			Severity:   "failure",
+			RawDetails: "org.opentest4j.AssertionFailedError: expected:<200> but was:<500>\n\tat org.junit.jupiter.api.AssertionUtils.fail(AssertionUtils.java:55)\n\tat com.example.AppTest.testLogin(AppTest.java:42)",
+		},
+	}, result.Annotations)
+
+	assert.True(t, result.Failed)
+	assert.Equal(t, "4 of 6 tests failed", result.Title)
+	assert.Equal(t, "6 tests: 1 passed, 3 failed, 1 errored, 1 skipped in 3.6s\n\n"+
+		"Failures without a location:\n\n- integration.test_checkout: Timed out after 100ms", result.Summary)
+}
+
+func TestJUnit_Passed(t *testing.T) {
+	report := `<testsuite name="jest" tests="2">
+  <testcase classname="App renders" name="App renders" time="0.012"/>
+  <testcase classname="App" name="handles clicks" time="0.003"/>
+</testsuite>`
+	result, err := parser.NewJUnit(bytes.NewBufferString(report)).Run()
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Annotations)
+	assert.False(t, result.Failed)
+	assert.Equal(t, "2 tests passed", result.Title)
+	assert.Equal(t, "2 tests: 2 passed, 0 failed, 0 errored, 0 skipped in 15ms", result.Summary)
+}
+
+func TestJUnit_OneTest(t *testing.T) {
+	report := `<testsuite><testcase name="test_one"><failure>boom</failure></testcase></testsuite>`
+	result, err := parser.NewJUnit(bytes.NewBufferString(report)).Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, "1 of 1 test failed", result.Title)
+	assert.Equal(t, "1 test: 0 passed, 1 failed, 0 errored, 0 skipped in 0s\n\n"+
+		"Failures without a location:\n\n- test_one: boom", result.Summary)
+}
+
+func TestJUnit_Invalid(t *testing.T) {
+	_, err := parser.NewJUnit(bytes.NewBufferString(`<testsuite><testcase name="a">`)).Run()
+	assert.Error(t, err)
+}
@@ -40,10 +40,21 @@ func extractMypy(match []string) (Annotation, error) {
 	}
 
 	return Annotation{
-		Path:    match[1],
-		Level:   LevelError,
-		Line:    line,
-		EndLine: line,
-		Message: match[4],
+		Path:     match[1],
+		Level:    mypyLevel(match[3]),
+		Severity: match[3],
+		Line:     line,
+		EndLine:  line,
+		Message:  match[4],
 	}, nil
 }
+
+func mypyLevel(severity string) Level {
+	switch severity {
+	case "note":
+		return LevelNotice
+	case "warning":
+		return LevelWarning
+	}
+	return LevelError
+}
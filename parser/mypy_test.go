@@ -47,3 +47,19 @@ Found 1 error in 1 file (checked 3 source files)`)
 	assert.Equal(0, a.Column)
 	assert.Equal(`Argument 1 to "main" has incompatible type "int"; expected "str"`, a.Message)
 }
+
+func TestMypy_Severities(t *testing.T) {
+	assert := assert.New(t)
+
+	linter := makeMypyLinter(`
+main.py:6: error: Name "foo" is not defined
+main.py:7: note: Revealed type is "builtins.int"
+main.py:8: warning: Unused "type: ignore" comment`)
+	results, err := linter.Run()
+	require.NoError(t, err, "Error running parser")
+	require.Equal(t, 3, len(results.Annotations))
+	assert.Equal(parser.LevelError, results.Annotations[0].Level)
+	assert.Equal(parser.LevelNotice, results.Annotations[1].Level)
+	assert.Equal("note", results.Annotations[1].Severity)
+	assert.Equal(parser.LevelWarning, results.Annotations[2].Level)
+}
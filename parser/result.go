@@ -19,6 +19,11 @@
 
 package parser
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Level represents an annotation level
 type Level string
 
@@ -27,16 +32,38 @@ const (
 	LevelWarning Level = "warning"
 	// LevelError is the error level
 	LevelError Level = "failure"
+	// LevelNotice is the notice level, for informational annotations
+	LevelNotice Level = "notice"
 )
 
+// ParseLevel converts a level name such as "warning" or "error" to a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "failure", "error":
+		return LevelError, nil
+	case "warning", "warn":
+		return LevelWarning, nil
+	case "notice", "note", "info":
+		return LevelNotice, nil
+	}
+	return "", fmt.Errorf("unknown annotation level: %s", name)
+}
+
 // Annotation represents a line-level annotation
 type Annotation struct {
 	Path    string `json:"path"`
 	Line    int    `json:"start_line"`
 	EndLine int    `json:"end_line"`
-	Column  int    `json:"column"`
-	Message string `json:"message"`
-	Level   Level  `json:"annotation_level"`
+	// Columns are only accepted by GitHub when Line and EndLine are the same
+	Column    int    `json:"start_column,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
+	Level     Level  `json:"annotation_level"`
+	// RawDetails holds extra details, such as a suggested fix
+	RawDetails string `json:"raw_details,omitempty"`
+	// Severity is the tool's own name for the annotation's severity, if any
+	Severity string `json:"-"`
 }
 
 // Result holds the output of a parser
@@ -44,4 +71,32 @@ type Result struct {
 	Annotations []Annotation `json:"annotations,omitempty"`
 	Title       string       `json:"title"`
 	Summary     string       `json:"summary"`
+	// Failed is set when the tool failed in a way annotations may not cover,
+	// such as a failing test without a location, so the check fails without any
+	Failed bool `json:"-"`
+}
+
+// annotationRange converts a 1-based range with an exclusive end column, as
+// reported by most tools, to the line and column range of an annotation.
+// GitHub only accepts columns for annotations on a single line.
+func annotationRange(startLine, endLine, startColumn, exclusiveEndColumn int) (line, end, column, endColumn int) {
+	line = startLine
+	if line < 1 {
+		line = 1
+	}
+	end = endLine
+	if end < line {
+		end = line
+	}
+	if line != end {
+		return line, end, 0, 0
+	}
+
+	column = startColumn
+	if exclusiveEndColumn > column+1 {
+		endColumn = exclusiveEndColumn - 1
+	} else if column > 0 {
+		endColumn = column
+	}
+	return line, end, column, endColumn
 }
@@ -0,0 +1,211 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sarifLog is the subset of the SARIF 2.1.0 format used for annotations
+type sarifLog struct {
+	Runs []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver sarifDriver `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string `json:"id"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Level     string `json:"level"`
+	Kind      string `json:"kind"`
+	Message   struct {
+		Text     string `json:"text"`
+		Markdown string `json:"markdown"`
+	} `json:"message"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region sarifRegion `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarif struct {
+	reader io.Reader
+}
+
+// NewSarif instantiates a parser for SARIF 2.1.0 logs from a reader
+func NewSarif(reader io.Reader) Parser {
+	return sarif{
+		reader: reader,
+	}
+}
+
+func (s sarif) Run() (Result, error) {
+	log := sarifLog{}
+	if err := json.NewDecoder(s.reader).Decode(&log); err != nil {
+		return Result{}, fmt.Errorf("decode SARIF log: %w", err)
+	}
+
+	annotations := []Annotation{}
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			a, ok := run.annotation(result)
+			if !ok {
+				continue
+			}
+			annotations = append(annotations, a)
+		}
+	}
+
+	return Result{
+		Annotations: annotations,
+	}, nil
+}
+
+func (r sarifRun) rule(result sarifResult) (sarifRule, bool) {
+	rules := r.Tool.Driver.Rules
+	if result.RuleIndex != nil && *result.RuleIndex >= 0 && *result.RuleIndex < len(rules) {
+		return rules[*result.RuleIndex], true
+	}
+	for _, rule := range rules {
+		if rule.ID == result.RuleID {
+			return rule, true
+		}
+	}
+	return sarifRule{}, false
+}
+
+func (r sarifRun) annotation(result sarifResult) (Annotation, bool) {
+	// Only failures have a meaningful level, other kinds (e.g. "pass") aren't issues
+	if result.Kind != "" && result.Kind != "fail" {
+		return Annotation{}, false
+	}
+	if len(result.Locations) == 0 {
+		logrus.WithField("rule", result.RuleID).Debug("Skipping SARIF result without a location")
+		return Annotation{}, false
+	}
+
+	rule, hasRule := r.rule(result)
+	ruleID := result.RuleID
+	if ruleID == "" {
+		ruleID = rule.ID
+	}
+
+	levelName := result.Level
+	if levelName == "" && hasRule {
+		levelName = rule.DefaultConfiguration.Level
+	}
+
+	location := result.Locations[0].PhysicalLocation
+	path, err := sarifPath(location.ArtifactLocation.URI)
+	if err != nil {
+		logrus.WithError(err).Errorf("Unable to read SARIF result location: %s", location.ArtifactLocation.URI)
+		return Annotation{}, false
+	}
+
+	message := result.Message.Text
+	if message == "" {
+		message = result.Message.Markdown
+	}
+
+	a := Annotation{
+		Path:     path,
+		Level:    sarifLevel(levelName),
+		Severity: levelName,
+		Title:    sarifTitle(r.Tool.Driver.Name, ruleID),
+		Message:  message,
+	}
+	a.Line, a.EndLine, a.Column, a.EndColumn = location.Region.lines()
+	return a, true
+}
+
+// lines converts a SARIF region to the line and column range of an annotation
+func (r sarifRegion) lines() (line, endLine, column, endColumn int) {
+	return annotationRange(r.StartLine, r.EndLine, r.StartColumn, r.EndColumn)
+}
+
+func sarifLevel(level string) Level {
+	switch level {
+	case "error":
+		return LevelError
+	case "note", "none":
+		return LevelNotice
+	}
+	// SARIF treats results without a level as warnings
+	return LevelWarning
+}
+
+func sarifTitle(tool string, ruleID string) string {
+	if ruleID == "" {
+		return tool
+	}
+	if tool == "" {
+		return ruleID
+	}
+	return fmt.Sprintf("%s (%s)", ruleID, tool)
+}
+
+func sarifPath(uri string) (string, error) {
+	if strings.HasPrefix(uri, "file:") {
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			return "", err
+		}
+		return relativePath(parsed.Path), nil
+	}
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return "", err
+	}
+	return relativePath(path), nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Rover.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package parser_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/roverdotcom/checkbridge/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSarif = `{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {
+      "driver": {
+        "name": "gosec",
+        "rules": [
+          {"id": "G104", "defaultConfiguration": {"level": "warning"}},
+          {"id": "G101", "defaultConfiguration": {"level": "error"}}
+        ]
+      }
+    },
+    "results": [
+      {
+        "ruleId": "G104",
+        "ruleIndex": 0,
+        "level": "error",
+        "message": {"text": "Errors unhandled."},
+        "locations": [{
+          "physicalLocation": {
+            "artifactLocation": {"uri": "cmd/main.go"},
+            "region": {"startLine": 12, "startColumn": 3, "endLine": 12, "endColumn": 20}
+          }
+        }]
+      },
+      {
+        "ruleId": "G101",
+        "message": {"text": "Potential hardcoded credentials"},
+        "locations": [{
+          "physicalLocation": {
+            "artifactLocation": {"uri": "file:///src/config%20files/creds.go"},
+            "region": {"startLine": 4, "startColumn": 1, "endLine": 6, "endColumn": 2}
+          }
+        }]
+      },
+      {
+        "ruleId": "G999",
+        "level": "note",
+        "message": {"text": "Something to look at"},
+        "locations": [{
+          "physicalLocation": {
+            "artifactLocation": {"uri": "README.md"}
+          }
+        }]
+      },
+      {
+        "ruleId": "G104",
+        "kind": "pass",
+        "message": {"text": "Not an issue"},
+        "locations": [{
+          "physicalLocation": {
+            "artifactLocation": {"uri": "cmd/main.go"},
+            "region": {"startLine": 1}
+          }
+        }]
+      },
+      {
+        "ruleId": "G104",
+        "message": {"text": "No location"}
+      }
+    ]
+  }]
+}`
+
+func TestSarif_Results(t *testing.T) {
+	assert := assert.New(t)
+
+	result, err := parser.NewSarif(bytes.NewBufferString(testSarif)).Run()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(result.Annotations))
+
+	a := result.Annotations[0]
+	assert.Equal("cmd/main.go", a.Path)
+	assert.Equal(parser.LevelError, a.Level)
+	assert.Equal("G104 (gosec)", a.Title)
+	assert.Equal("Errors unhandled.", a.Message)
+	assert.Equal(12, a.Line)
+	assert.Equal(12, a.EndLine)
+	assert.Equal(3, a.Column)
+	assert.Equal(19, a.EndColumn)
+
+	a = result.Annotations[1]
+	assert.Equal("/src/config files/creds.go", a.Path)
+	assert.Equal(parser.LevelError, a.Level, "expected level from rule configuration")
+	assert.Equal(4, a.Line)
+	assert.Equal(6, a.EndLine)
+	assert.Equal(0, a.Column, "columns only valid on a single line")
+	assert.Equal(0, a.EndColumn)
+
+	a = result.Annotations[2]
+	assert.Equal("README.md", a.Path)
+	assert.Equal(parser.LevelNotice, a.Level)
+	assert.Equal("note", a.Severity)
+	assert.Equal(1, a.Line)
+	assert.Equal(1, a.EndLine)
+}
+
+func TestSarif_Invalid(t *testing.T) {
+	_, err := parser.NewSarif(bytes.NewBufferString(`<html>`)).Run()
+	assert.Error(t, err)
+}